@@ -1,26 +1,83 @@
 package main
 
 import (
+	"context"
 	"log"
 	"net/http"
 	"os"
+	"strconv"
+	"strings"
+	"time"
 
 	"example.com/helloapi/internal/handlers"
+	"example.com/helloapi/internal/health"
+	"example.com/helloapi/internal/i18n"
+	"example.com/helloapi/internal/metrics"
 	"example.com/helloapi/internal/middlewares"
+	"example.com/helloapi/internal/server"
+	"example.com/helloapi/internal/storage"
 )
 
 func main() {
+	store, err := getUserStore()
+	if err != nil {
+		log.Fatalf("failed to open user store: %v", err)
+	}
+
+	bundle, err := i18n.LoadEmbedded("en")
+	if err != nil {
+		log.Fatalf("failed to load message catalogs: %v", err)
+	}
+	bundle.Fallbacks["be"] = []string{"ru"}
+	bundle.Fallbacks["kk"] = []string{"ru"}
+
+	registry := health.NewRegistry(2 * time.Second)
+	registry.Register(health.Check{
+		Name:     "goroutines",
+		Kind:     health.Liveness,
+		Timeout:  time.Second,
+		Critical: false,
+		Fn:       health.GoroutineCheck(10000),
+	})
+	registry.Register(health.Check{
+		Name:     "users",
+		Kind:     health.Readiness,
+		Timeout:  time.Second,
+		Critical: true,
+		Fn: func(ctx context.Context) error {
+			_, err := store.List()
+			return err
+		},
+	})
+
+	metricsRegistry := metrics.NewRegistry()
+	httpMetrics := metrics.NewHTTPMetrics(metricsRegistry)
+	metricsRegistry.Register(metrics.NewRuntimeCollector())
+
 	mux := http.NewServeMux()
 
-	mux.HandleFunc("/hello", handlers.GetGreetingsHandler)
-	mux.HandleFunc("/user", handlers.GetUserHandler)
-	mux.HandleFunc("/health", handlers.GrtHealthCheckHandler)
+	mux.HandleFunc("/hello", handlers.GetGreetingsHandler(bundle))
+	mux.Handle("/user", http.RedirectHandler("/users", http.StatusPermanentRedirect))
+	handlers.NewUsersHandler(store).Register(mux)
+	mux.HandleFunc("/health", handlers.GetHealthCheckHandler(registry, health.Readiness))
+	mux.HandleFunc("/health/live", handlers.GetHealthCheckHandler(registry, health.Liveness))
+	mux.HandleFunc("/health/ready", handlers.GetHealthCheckHandler(registry, health.Readiness))
+	mux.Handle("GET /metrics", metricsRegistry.Handler())
 
-	muxWithMiddlewares := middlewares.LoggingMiddleware(mux)
-	addr := getAddr()
+	muxWithMiddlewares := middlewares.NewMetricsMiddleware(httpMetrics, mux)(mux)
+	loggingConfig, err := getLoggingConfig()
+	if err != nil {
+		log.Fatalf("invalid logging config: %v", err)
+	}
+	logging, err := middlewares.NewLoggingMiddleware(loggingConfig)
+	if err != nil {
+		log.Fatalf("invalid logging config: %v", err)
+	}
+	muxWithMiddlewares = logging(muxWithMiddlewares)
 
-	log.Printf("Starting on %s ...", addr)
-	log.Fatal(http.ListenAndServe(addr, muxWithMiddlewares))
+	if err := server.Run(getServerConfig(), muxWithMiddlewares); err != nil {
+		log.Fatal(err)
+	}
 }
 
 func getAddr() string {
@@ -30,3 +87,75 @@ func getAddr() string {
 	}
 	return ":" + port
 }
+
+func getServerConfig() server.Config {
+	cfg := server.DefaultConfig()
+	cfg.Addr = getAddr()
+	cfg.ReadHeaderTimeout = getDuration("HTTP_READ_HEADER_TIMEOUT", cfg.ReadHeaderTimeout)
+	cfg.ReadTimeout = getDuration("HTTP_READ_TIMEOUT", cfg.ReadTimeout)
+	cfg.WriteTimeout = getDuration("HTTP_WRITE_TIMEOUT", cfg.WriteTimeout)
+	cfg.IdleTimeout = getDuration("HTTP_IDLE_TIMEOUT", cfg.IdleTimeout)
+	cfg.ShutdownTimeout = getDuration("HTTP_SHUTDOWN_TIMEOUT", cfg.ShutdownTimeout)
+	cfg.TLSCertFile = os.Getenv("TLS_CERT_FILE")
+	cfg.TLSKeyFile = os.Getenv("TLS_KEY_FILE")
+	cfg.DevTLS, _ = strconv.ParseBool(os.Getenv("TLS_DEV"))
+	return cfg
+}
+
+func getDuration(key string, def time.Duration) time.Duration {
+	v := os.Getenv(key)
+	if v == "" {
+		return def
+	}
+	d, err := time.ParseDuration(v)
+	if err != nil {
+		log.Printf("invalid %s=%q, using %s", key, v, def)
+		return def
+	}
+	return d
+}
+
+func getUserStore() (storage.UserStore, error) {
+	if path := os.Getenv("USERS_FILE"); path != "" {
+		return storage.NewFileUserStore(path)
+	}
+	return storage.NewMemoryUserStore(), nil
+}
+
+func getLoggingConfig() (middlewares.LoggingConfig, error) {
+	cfg := middlewares.LoggingConfig{
+		Format:     os.Getenv("LOG_FORMAT"),
+		SkipPaths:  []string{"/health"},
+		SampleRate: 1,
+	}
+	if cfg.Format == "" {
+		cfg.Format = "json"
+	}
+	if fields := os.Getenv("LOG_FIELDS"); fields != "" {
+		cfg.Fields = splitList(fields)
+	}
+	if paths, ok := os.LookupEnv("LOG_SKIP_PATHS"); ok {
+		cfg.SkipPaths = splitList(paths)
+	}
+	if rate, err := strconv.Atoi(os.Getenv("LOG_SAMPLE_RATE")); err == nil && rate > 0 {
+		cfg.SampleRate = rate
+	}
+	if proxies := os.Getenv("LOG_TRUSTED_PROXIES"); proxies != "" {
+		trusted, err := middlewares.ParseTrustedProxies(splitList(proxies))
+		if err != nil {
+			return cfg, err
+		}
+		cfg.TrustedProxies = trusted
+	}
+	return cfg, nil
+}
+
+func splitList(s string) []string {
+	var out []string
+	for _, part := range strings.Split(s, ",") {
+		if part = strings.TrimSpace(part); part != "" {
+			out = append(out, part)
+		}
+	}
+	return out
+}
@@ -2,4 +2,10 @@ module example.com/helloapi
 
 go 1.22.4
 
-require github.com/google/uuid v1.6.0
+require (
+	github.com/BurntSushi/toml v1.4.0
+	github.com/google/uuid v1.6.0
+	github.com/vmihailenco/msgpack/v5 v5.4.1
+)
+
+require github.com/vmihailenco/tagparser/v2 v2.0.0 // indirect
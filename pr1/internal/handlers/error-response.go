@@ -0,0 +1,21 @@
+package handlers
+
+import (
+	"encoding/xml"
+	"net/http"
+
+	"example.com/helloapi/internal/render"
+)
+
+type errorResponse struct {
+	XMLName xml.Name `json:"-" xml:"error"`
+	Error   string   `json:"error" xml:"message"`
+}
+
+func (e errorResponse) PlainText() string {
+	return "error: " + e.Error
+}
+
+func writeError(w http.ResponseWriter, r *http.Request, code int, msg string) {
+	render.Render(w, r, code, errorResponse{Error: msg})
+}
@@ -1,7 +1,60 @@
 package handlers
 
-import "net/http"
+import (
+	"encoding/xml"
+	"net/http"
+	"strings"
 
-func GetGreetingsHandler(w http.ResponseWriter, r *http.Request) {
-	w.Write([]byte("Hello, world!"))
+	"example.com/helloapi/internal/i18n"
+	"example.com/helloapi/internal/render"
+)
+
+type greetingResponse struct {
+	XMLName xml.Name `json:"-" xml:"greeting"`
+	Message string   `json:"message" xml:"message"`
+	Lang    string   `json:"lang" xml:"lang,attr"`
+}
+
+func (g greetingResponse) PlainText() string {
+	return g.Message
+}
+
+var greetingRenderer = render.New(render.Text, render.JSON, render.XML, render.MsgPack)
+
+func GetGreetingsHandler(bundle *i18n.Bundle) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		query := r.URL.Query()
+		catalog := bundle.Negotiate(query.Get("lang"), r.Header.Get("Accept-Language"))
+
+		var names []string
+		for _, name := range query["name"] {
+			if name = strings.TrimSpace(name); name != "" {
+				names = append(names, name)
+			}
+		}
+
+		greeting := catalog.Text("greeting", nil)
+		if len(names) > 0 {
+			greeting = catalog.Text("greeting_named", map[string]string{
+				"names": joinNames(names, catalog.Text("list_and", nil)),
+			})
+			if len(names) > 1 {
+				greeting += " " + catalog.Plural("guests", len(names), nil)
+			}
+		}
+
+		w.Header().Set("Content-Language", catalog.Lang)
+		w.Header().Add("Vary", "Accept-Language")
+		greetingRenderer.Render(w, r, http.StatusOK, greetingResponse{
+			Message: greeting,
+			Lang:    catalog.Lang,
+		})
+	}
+}
+
+func joinNames(names []string, and string) string {
+	if len(names) == 1 {
+		return names[0]
+	}
+	return strings.Join(names[:len(names)-1], ", ") + " " + and + " " + names[len(names)-1]
 }
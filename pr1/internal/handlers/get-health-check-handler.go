@@ -1,23 +1,22 @@
 package handlers
 
 import (
-	"encoding/json"
 	"net/http"
-	"time"
+
+	"example.com/helloapi/internal/health"
+	"example.com/helloapi/internal/render"
 )
 
-type healthCheckResponse struct {
-	Status string `json:"status"`
-	Time   string `json:"time"`
-}
+func GetHealthCheckHandler(registry *health.Registry, kind health.Kind) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		report := registry.Run(r.Context(), kind)
 
-func GrtHealthCheckHandler(w http.ResponseWriter, r *http.Request) {
-	now := time.Now()
-	formattedTime := now.Format(time.RFC3339)
+		code := http.StatusOK
+		if report.Status == health.StatusDown {
+			code = http.StatusServiceUnavailable
+		}
 
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(healthCheckResponse{
-		Status: "ok",
-		Time:   formattedTime,
-	})
+		w.Header().Set("Cache-Control", "no-store")
+		render.Render(w, r, code, report)
+	}
 }
@@ -0,0 +1,127 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"example.com/helloapi/internal/render"
+	"example.com/helloapi/internal/storage"
+	"github.com/google/uuid"
+)
+
+type UsersHandler struct {
+	Store storage.UserStore
+}
+
+func NewUsersHandler(store storage.UserStore) *UsersHandler {
+	return &UsersHandler{Store: store}
+}
+
+type userRequest struct {
+	Name string `json:"name"`
+}
+
+func (h *UsersHandler) Register(mux *http.ServeMux) {
+	mux.HandleFunc("GET /users", h.ListUsers)
+	mux.HandleFunc("POST /users", h.CreateUser)
+	mux.HandleFunc("GET /users/{id}", h.GetUser)
+	mux.HandleFunc("PUT /users/{id}", h.UpdateUser)
+	mux.HandleFunc("DELETE /users/{id}", h.DeleteUser)
+}
+
+func (h *UsersHandler) ListUsers(w http.ResponseWriter, r *http.Request) {
+	users, err := h.Store.List()
+	if err != nil {
+		writeError(w, r, http.StatusInternalServerError, "failed to list users")
+		return
+	}
+	render.Render(w, r, http.StatusOK, users)
+}
+
+func (h *UsersHandler) GetUser(w http.ResponseWriter, r *http.Request) {
+	id, ok := userID(w, r)
+	if !ok {
+		return
+	}
+	user, err := h.Store.Get(id)
+	if err != nil {
+		writeStoreError(w, r, err)
+		return
+	}
+	render.Render(w, r, http.StatusOK, user)
+}
+
+func (h *UsersHandler) CreateUser(w http.ResponseWriter, r *http.Request) {
+	req, ok := decodeUserRequest(w, r)
+	if !ok {
+		return
+	}
+	user, err := h.Store.Create(req.Name)
+	if err != nil {
+		writeStoreError(w, r, err)
+		return
+	}
+	w.Header().Set("Location", "/users/"+user.ID)
+	render.Render(w, r, http.StatusCreated, user)
+}
+
+func (h *UsersHandler) UpdateUser(w http.ResponseWriter, r *http.Request) {
+	id, ok := userID(w, r)
+	if !ok {
+		return
+	}
+	req, ok := decodeUserRequest(w, r)
+	if !ok {
+		return
+	}
+	user, err := h.Store.Update(id, req.Name)
+	if err != nil {
+		writeStoreError(w, r, err)
+		return
+	}
+	render.Render(w, r, http.StatusOK, user)
+}
+
+func (h *UsersHandler) DeleteUser(w http.ResponseWriter, r *http.Request) {
+	id, ok := userID(w, r)
+	if !ok {
+		return
+	}
+	if err := h.Store.Delete(id); err != nil {
+		writeStoreError(w, r, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func userID(w http.ResponseWriter, r *http.Request) (string, bool) {
+	id, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		writeError(w, r, http.StatusBadRequest, "invalid user id")
+		return "", false
+	}
+	return id.String(), true
+}
+
+func decodeUserRequest(w http.ResponseWriter, r *http.Request) (userRequest, bool) {
+	var req userRequest
+	dec := json.NewDecoder(http.MaxBytesReader(w, r.Body, 1<<20))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&req); err != nil {
+		writeError(w, r, http.StatusBadRequest, "invalid json: "+err.Error())
+		return req, false
+	}
+	return req, true
+}
+
+func writeStoreError(w http.ResponseWriter, r *http.Request, err error) {
+	switch {
+	case errors.Is(err, storage.ErrNotFound):
+		writeError(w, r, http.StatusNotFound, err.Error())
+	case errors.Is(err, storage.ErrInvalidName):
+		writeError(w, r, http.StatusUnprocessableEntity, err.Error())
+	default:
+		writeError(w, r, http.StatusInternalServerError, "internal error")
+	}
+}
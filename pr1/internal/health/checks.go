@@ -0,0 +1,16 @@
+package health
+
+import (
+	"context"
+	"fmt"
+	"runtime"
+)
+
+func GoroutineCheck(max int) CheckFunc {
+	return func(ctx context.Context) error {
+		if n := runtime.NumGoroutine(); n > max {
+			return fmt.Errorf("too many goroutines: %d > %d", n, max)
+		}
+		return nil
+	}
+}
@@ -0,0 +1,200 @@
+package health
+
+import (
+	"context"
+	"encoding/xml"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+type Kind string
+
+const (
+	Liveness  Kind = "live"
+	Readiness Kind = "ready"
+)
+
+type Status string
+
+const (
+	StatusOK       Status = "ok"
+	StatusDegraded Status = "degraded"
+	StatusDown     Status = "down"
+)
+
+type CheckFunc func(ctx context.Context) error
+
+type Check struct {
+	Name     string
+	Kind     Kind
+	Timeout  time.Duration
+	Critical bool
+	Fn       CheckFunc
+}
+
+type CheckResult struct {
+	Name      string  `json:"name" xml:"name,attr"`
+	Status    Status  `json:"status" xml:"status"`
+	LatencyMs float64 `json:"latency_ms" xml:"latency_ms"`
+	Error     string  `json:"error,omitempty" xml:"error,omitempty"`
+}
+
+type Report struct {
+	XMLName xml.Name      `json:"-" xml:"health"`
+	Status  Status        `json:"status" xml:"status"`
+	Time    string        `json:"time" xml:"time"`
+	Checks  []CheckResult `json:"checks" xml:"checks>check"`
+}
+
+func (r Report) PlainText() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "status: %s\ntime: %s", r.Status, r.Time)
+	for _, c := range r.Checks {
+		fmt.Fprintf(&b, "\n%s: %s (%.3fms)", c.Name, c.Status, c.LatencyMs)
+		if c.Error != "" {
+			fmt.Fprintf(&b, " %s", c.Error)
+		}
+	}
+	return b.String()
+}
+
+type cachedReport struct {
+	mu      sync.Mutex
+	report  Report
+	expires time.Time
+}
+
+type Registry struct {
+	mu       sync.RWMutex
+	checks   []Check
+	cacheTTL time.Duration
+	cache    map[Kind]*cachedReport
+}
+
+const defaultTimeout = 2 * time.Second
+
+func NewRegistry(cacheTTL time.Duration) *Registry {
+	return &Registry{
+		cacheTTL: cacheTTL,
+		cache: map[Kind]*cachedReport{
+			Liveness:  {},
+			Readiness: {},
+		},
+	}
+}
+
+func (r *Registry) Register(c Check) {
+	if c.Timeout <= 0 {
+		c.Timeout = defaultTimeout
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.checks = append(r.checks, c)
+}
+
+func (r *Registry) Run(ctx context.Context, kind Kind) Report {
+	entry, ok := r.cache[kind]
+	if !ok {
+		return r.run(ctx, kind)
+	}
+
+	// Параллельные пробы ждут один прогон и получают его результат из кэша
+	entry.mu.Lock()
+	defer entry.mu.Unlock()
+	if time.Now().Before(entry.expires) {
+		return entry.report
+	}
+	// Общий прогон не зависит от отмены запроса того, кто его запустил,
+	// иначе обрыв одного клиента закэширует ложный down для всех.
+	entry.report = r.run(context.WithoutCancel(ctx), kind)
+	entry.expires = time.Now().Add(r.cacheTTL)
+	return entry.report
+}
+
+func (r *Registry) run(ctx context.Context, kind Kind) Report {
+	r.mu.RLock()
+	checks := make([]Check, 0, len(r.checks))
+	for _, c := range r.checks {
+		if c.Kind == kind {
+			checks = append(checks, c)
+		}
+	}
+	r.mu.RUnlock()
+
+	results := make([]CheckResult, len(checks))
+	var wg sync.WaitGroup
+	for i, c := range checks {
+		wg.Add(1)
+		go func(i int, c Check) {
+			defer wg.Done()
+			results[i] = runCheck(ctx, c)
+		}(i, c)
+	}
+	wg.Wait()
+
+	sort.Slice(results, func(i, j int) bool { return results[i].Name < results[j].Name })
+
+	return Report{
+		Status: overallStatus(results),
+		Time:   time.Now().Format(time.RFC3339),
+		Checks: results,
+	}
+}
+
+func runCheck(parent context.Context, c Check) CheckResult {
+	ctx, cancel := context.WithTimeout(parent, c.Timeout)
+	defer cancel()
+
+	start := time.Now()
+	done := make(chan error, 1)
+	go func() {
+		defer func() {
+			if p := recover(); p != nil {
+				done <- fmt.Errorf("panic: %v", p)
+			}
+		}()
+		done <- c.Fn(ctx)
+	}()
+
+	var err error
+	select {
+	case err = <-done:
+	case <-ctx.Done():
+		// отмена вызывающего — не то же самое, что зависшая проверка
+		if parent.Err() != nil {
+			err = fmt.Errorf("canceled: %w", parent.Err())
+		} else {
+			err = fmt.Errorf("timeout after %s", c.Timeout)
+		}
+	}
+
+	res := CheckResult{
+		Name:      c.Name,
+		Status:    StatusOK,
+		LatencyMs: float64(time.Since(start).Microseconds()) / 1000,
+	}
+	if err != nil {
+		res.Error = err.Error()
+		res.Status = StatusDegraded
+		if c.Critical {
+			res.Status = StatusDown
+		}
+	}
+	return res
+}
+
+func overallStatus(results []CheckResult) Status {
+	status := StatusOK
+	for _, res := range results {
+		switch res.Status {
+		case StatusDown:
+			return StatusDown
+		case StatusDegraded:
+			status = StatusDegraded
+		}
+	}
+	return status
+}
@@ -0,0 +1,173 @@
+package i18n
+
+import (
+	"embed"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"path"
+	"strconv"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+)
+
+//go:embed locales/*
+var embeddedLocales embed.FS
+
+type Message map[string]string
+
+type Catalog struct {
+	Lang     string
+	messages map[string]Message
+	plural   PluralRule
+}
+
+type Bundle struct {
+	DefaultLang string
+	Fallbacks   map[string][]string
+	catalogs    map[string]*Catalog
+}
+
+func NewBundle(defaultLang string) *Bundle {
+	return &Bundle{
+		DefaultLang: defaultLang,
+		Fallbacks:   make(map[string][]string),
+		catalogs:    make(map[string]*Catalog),
+	}
+}
+
+func LoadEmbedded(defaultLang string) (*Bundle, error) {
+	b := NewBundle(defaultLang)
+	if err := b.LoadFS(embeddedLocales, "locales"); err != nil {
+		return nil, err
+	}
+	if _, ok := b.catalogs[defaultLang]; !ok {
+		return nil, fmt.Errorf("no catalog for default language %q", defaultLang)
+	}
+	return b, nil
+}
+
+func (b *Bundle) LoadFS(fsys fs.FS, dir string) error {
+	entries, err := fs.ReadDir(fsys, dir)
+	if err != nil {
+		return fmt.Errorf("read locales: %w", err)
+	}
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+		name := entry.Name()
+		ext := path.Ext(name)
+		lang := normalizeTag(strings.TrimSuffix(name, ext))
+
+		data, err := fs.ReadFile(fsys, path.Join(dir, name))
+		if err != nil {
+			return fmt.Errorf("read %s: %w", name, err)
+		}
+
+		raw := make(map[string]any)
+		switch ext {
+		case ".json":
+			err = json.Unmarshal(data, &raw)
+		case ".toml":
+			err = toml.Unmarshal(data, &raw)
+		default:
+			continue
+		}
+		if err != nil {
+			return fmt.Errorf("parse %s: %w", name, err)
+		}
+
+		catalog, err := newCatalog(lang, raw)
+		if err != nil {
+			return fmt.Errorf("load %s: %w", name, err)
+		}
+		b.catalogs[lang] = catalog
+	}
+	return nil
+}
+
+func (b *Bundle) Catalog(lang string) (*Catalog, bool) {
+	c, ok := b.catalogs[normalizeTag(lang)]
+	return c, ok
+}
+
+func (b *Bundle) Languages() []string {
+	langs := make([]string, 0, len(b.catalogs))
+	for lang := range b.catalogs {
+		langs = append(langs, lang)
+	}
+	return langs
+}
+
+func newCatalog(lang string, raw map[string]any) (*Catalog, error) {
+	c := &Catalog{
+		Lang:     lang,
+		messages: make(map[string]Message, len(raw)),
+		plural:   pluralRuleFor(lang),
+	}
+	for key, value := range raw {
+		switch v := value.(type) {
+		case string:
+			c.messages[key] = Message{pluralOther: v}
+		case map[string]any:
+			msg := make(Message, len(v))
+			for form, text := range v {
+				s, ok := text.(string)
+				if !ok {
+					return nil, fmt.Errorf("message %q: form %q must be a string", key, form)
+				}
+				msg[form] = s
+			}
+			if _, ok := msg[pluralOther]; !ok {
+				return nil, fmt.Errorf("message %q: missing %q form", key, pluralOther)
+			}
+			c.messages[key] = msg
+		default:
+			return nil, fmt.Errorf("message %q: unsupported value type %T", key, value)
+		}
+	}
+	return c, nil
+}
+
+func (c *Catalog) Has(key string) bool {
+	_, ok := c.messages[key]
+	return ok
+}
+
+func (c *Catalog) Text(key string, args map[string]string) string {
+	msg, ok := c.messages[key]
+	if !ok {
+		return key
+	}
+	return interpolate(msg[pluralOther], args)
+}
+
+func (c *Catalog) Plural(key string, count int, args map[string]string) string {
+	msg, ok := c.messages[key]
+	if !ok {
+		return key
+	}
+	text, ok := msg[c.plural(count)]
+	if !ok {
+		text = msg[pluralOther]
+	}
+
+	withCount := map[string]string{"count": strconv.Itoa(count)}
+	for k, v := range args {
+		withCount[k] = v
+	}
+	return interpolate(text, withCount)
+}
+
+func interpolate(text string, args map[string]string) string {
+	if len(args) == 0 {
+		return text
+	}
+	pairs := make([]string, 0, len(args)*2)
+	for k, v := range args {
+		pairs = append(pairs, "{"+k+"}", v)
+	}
+	return strings.NewReplacer(pairs...).Replace(text)
+}
@@ -0,0 +1,90 @@
+package i18n
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+)
+
+type languageRange struct {
+	tag string
+	q   float64
+}
+
+func ParseAcceptLanguage(header string) []string {
+	var ranges []languageRange
+	for _, part := range strings.Split(header, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		tag, params, _ := strings.Cut(part, ";")
+		q := 1.0
+		for _, param := range strings.Split(params, ";") {
+			key, value, ok := strings.Cut(strings.TrimSpace(param), "=")
+			if !ok || strings.TrimSpace(key) != "q" {
+				continue
+			}
+			parsed, err := strconv.ParseFloat(strings.TrimSpace(value), 64)
+			if err != nil || parsed < 0 || parsed > 1 {
+				parsed = 0
+			}
+			q = parsed
+		}
+		if q == 0 {
+			continue
+		}
+		ranges = append(ranges, languageRange{tag: normalizeTag(tag), q: q})
+	}
+
+	sort.SliceStable(ranges, func(i, j int) bool { return ranges[i].q > ranges[j].q })
+
+	tags := make([]string, 0, len(ranges))
+	for _, r := range ranges {
+		tags = append(tags, r.tag)
+	}
+	return tags
+}
+
+// Negotiate выбирает язык: сначала ?lang=, затем Accept-Language по убыванию q,
+// для каждого тега проходя цепочку ru-RU -> ru -> Fallbacks["ru"], и в конце язык по умолчанию.
+func (b *Bundle) Negotiate(override, acceptLanguage string) *Catalog {
+	var candidates []string
+	if override != "" {
+		candidates = append(candidates, normalizeTag(override))
+	}
+	candidates = append(candidates, ParseAcceptLanguage(acceptLanguage)...)
+
+	for _, tag := range candidates {
+		if tag == "*" {
+			break
+		}
+		for _, lang := range b.fallbackChain(tag) {
+			if c, ok := b.catalogs[lang]; ok {
+				return c
+			}
+		}
+	}
+	return b.catalogs[b.DefaultLang]
+}
+
+func (b *Bundle) fallbackChain(tag string) []string {
+	chain := []string{tag}
+	for t := tag; strings.Contains(t, "-"); {
+		t = t[:strings.LastIndex(t, "-")]
+		chain = append(chain, t)
+	}
+	for _, t := range append([]string(nil), chain...) {
+		chain = append(chain, b.Fallbacks[t]...)
+	}
+	return chain
+}
+
+func normalizeTag(tag string) string {
+	return strings.ToLower(strings.ReplaceAll(strings.TrimSpace(tag), "_", "-"))
+}
+
+func baseLanguage(tag string) string {
+	base, _, _ := strings.Cut(normalizeTag(tag), "-")
+	return base
+}
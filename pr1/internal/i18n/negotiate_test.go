@@ -0,0 +1,53 @@
+package i18n
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestParseAcceptLanguage(t *testing.T) {
+	for _, tc := range []struct {
+		header string
+		want   []string
+	}{
+		{"", []string{}},
+		{"ru-RU", []string{"ru-ru"}},
+		{"en;q=0.5, ru_RU, de;q=0.8", []string{"ru-ru", "de", "en"}},
+		// при равных q сохраняется порядок заголовка
+		{"fr;q=0.7, de;q=0.7", []string{"fr", "de"}},
+		// q=0 и недопустимые q исключают язык
+		{"en;q=0, ru;q=abc, de;q=2, fr", []string{"fr"}},
+		{"en; q = 0.3 ,, *;q=0.1", []string{"en", "*"}},
+	} {
+		if got := ParseAcceptLanguage(tc.header); !slices.Equal(got, tc.want) {
+			t.Errorf("ParseAcceptLanguage(%q) = %v, want %v", tc.header, got, tc.want)
+		}
+	}
+}
+
+func TestBundle_Negotiate(t *testing.T) {
+	b, err := LoadEmbedded("en")
+	if err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	b.Fallbacks["uk"] = []string{"ru"}
+
+	for _, tc := range []struct {
+		override, accept string
+		want             string
+	}{
+		{"", "", "en"},
+		{"", "ru-RU,en;q=0.5", "ru"},
+		{"", "de, ru;q=0.5", "ru"},
+		{"", "uk-UA", "ru"},
+		{"en", "ru", "en"},
+		{"de", "ru", "ru"},
+		// * означает «любой», дальше по списку не ищем
+		{"", "de, *, ru;q=0.1", "en"},
+		{"", "ru;q=0, de", "en"},
+	} {
+		if got := b.Negotiate(tc.override, tc.accept); got.Lang != tc.want {
+			t.Errorf("Negotiate(%q, %q) = %s, want %s", tc.override, tc.accept, got.Lang, tc.want)
+		}
+	}
+}
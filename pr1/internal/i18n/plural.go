@@ -0,0 +1,45 @@
+package i18n
+
+type PluralRule func(n int) string
+
+const (
+	pluralOne   = "one"
+	pluralFew   = "few"
+	pluralMany  = "many"
+	pluralOther = "other"
+)
+
+var pluralRules = map[string]PluralRule{
+	"en": pluralEnglish,
+	"ru": pluralRussian,
+}
+
+func pluralEnglish(n int) string {
+	if n == 1 {
+		return pluralOne
+	}
+	return pluralOther
+}
+
+// Правила CLDR для русского языка: 1, 21, 31 — one; 2–4, 22–24 — few; остальное — many.
+func pluralRussian(n int) string {
+	if n < 0 {
+		n = -n
+	}
+	mod10, mod100 := n%10, n%100
+	switch {
+	case mod10 == 1 && mod100 != 11:
+		return pluralOne
+	case mod10 >= 2 && mod10 <= 4 && (mod100 < 12 || mod100 > 14):
+		return pluralFew
+	default:
+		return pluralMany
+	}
+}
+
+func pluralRuleFor(lang string) PluralRule {
+	if rule, ok := pluralRules[baseLanguage(lang)]; ok {
+		return rule
+	}
+	return pluralEnglish
+}
@@ -0,0 +1,65 @@
+package metrics
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+)
+
+type HTTPMetrics struct {
+	requests *CounterVec
+	errors   *CounterVec
+	duration *HistogramVec
+	inFlight *GaugeVec
+}
+
+func NewHTTPMetrics(reg *Registry) *HTTPMetrics {
+	m := &HTTPMetrics{
+		requests: NewCounterVec("http_requests_total",
+			"Total number of HTTP requests.", "route", "method", "status_class"),
+		errors: NewCounterVec("http_request_errors_total",
+			"Total number of HTTP requests answered with a 5xx status.", "route", "method", "status_class"),
+		duration: NewHistogramVec("http_request_duration_seconds",
+			"HTTP request latency in seconds.", DefaultBuckets, "route", "method", "status_class"),
+		inFlight: NewGaugeVec("http_requests_in_flight",
+			"Number of HTTP requests currently being served.", "route"),
+	}
+	reg.Register(m.requests)
+	reg.Register(m.errors)
+	reg.Register(m.duration)
+	reg.Register(m.inFlight)
+	return m
+}
+
+func (m *HTTPMetrics) Start(route string) {
+	m.inFlight.Add(1, route)
+}
+
+func (m *HTTPMetrics) Finish(route, method string, status int, elapsed time.Duration) {
+	m.inFlight.Add(-1, route)
+
+	method = normalizeMethod(method)
+	class := StatusClass(status)
+	m.requests.Inc(route, method, class)
+	if status >= http.StatusInternalServerError {
+		m.errors.Inc(route, method, class)
+	}
+	m.duration.Observe(elapsed.Seconds(), route, method, class)
+}
+
+func StatusClass(status int) string {
+	if status < 100 || status > 599 {
+		return "unknown"
+	}
+	return strconv.Itoa(status/100) + "xx"
+}
+
+// Нестандартные методы сводим в один лейбл, чтобы клиент не мог раздуть число серий.
+func normalizeMethod(method string) string {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodPost, http.MethodPut, http.MethodPatch,
+		http.MethodDelete, http.MethodOptions, http.MethodConnect, http.MethodTrace:
+		return method
+	}
+	return "OTHER"
+}
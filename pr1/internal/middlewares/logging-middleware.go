@@ -1,16 +1,206 @@
 package middlewares
 
 import (
-	"log"
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
 	"net/http"
+	"net/netip"
+	"os"
+	"strings"
+	"sync/atomic"
 	"time"
 )
 
-func LoggingMiddleware(next http.Handler) http.Handler {
-	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		start := time.Now()
-		log.Printf("Request: %s %s, start time: %s\n", r.Method, r.URL.Path, start.Format(time.RFC3339))
-		next.ServeHTTP(w, r)
-		log.Printf("Request %s %s completed, duration: %s\n", r.Method, r.URL.Path, time.Since(start))
-	})
+const (
+	FieldMethod    = "method"
+	FieldPath      = "path"
+	FieldQuery     = "query"
+	FieldStatus    = "status"
+	FieldBytes     = "bytes"
+	FieldDuration  = "duration_ms"
+	FieldClientIP  = "client_ip"
+	FieldUserAgent = "user_agent"
+	FieldReferer   = "referer"
+	FieldProto     = "proto"
+)
+
+var DefaultLogFields = []string{
+	FieldMethod, FieldPath, FieldStatus, FieldBytes, FieldDuration, FieldClientIP, FieldUserAgent,
+}
+
+type LoggingConfig struct {
+	Format     string
+	Fields     []string
+	SkipPaths  []string
+	SampleRate int
+	Output     io.Writer
+	// TrustedProxies — адреса прокси, чьим X-Forwarded-For и X-Real-IP можно верить.
+	// Для остальных клиентов в журнал пишется адрес соединения.
+	TrustedProxies []netip.Prefix
+}
+
+func NewLoggingMiddleware(cfg LoggingConfig) (func(http.Handler) http.Handler, error) {
+	if cfg.Output == nil {
+		cfg.Output = os.Stdout
+	}
+	if len(cfg.Fields) == 0 {
+		cfg.Fields = DefaultLogFields
+	}
+	if cfg.SampleRate < 1 {
+		cfg.SampleRate = 1
+	}
+
+	var handler slog.Handler
+	switch cfg.Format {
+	case "json":
+		handler = slog.NewJSONHandler(cfg.Output, nil)
+	case "logfmt", "text":
+		handler = slog.NewTextHandler(cfg.Output, nil)
+	default:
+		return nil, fmt.Errorf("unknown log format %q", cfg.Format)
+	}
+	logger := slog.New(handler)
+
+	var counter atomic.Uint64
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if skipPath(cfg.SkipPaths, r.URL.Path) {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			start := time.Now()
+			rec := newResponseRecorder(w)
+			next.ServeHTTP(rec, r)
+
+			// Успешные запросы логируются выборочно (1 из N), ошибки — всегда
+			if rec.status < http.StatusBadRequest && cfg.SampleRate > 1 {
+				if counter.Add(1)%uint64(cfg.SampleRate) != 0 {
+					return
+				}
+			}
+
+			attrs := make([]slog.Attr, 0, len(cfg.Fields))
+			for _, field := range cfg.Fields {
+				if attr, ok := logField(field, r, rec, start, cfg.TrustedProxies); ok {
+					attrs = append(attrs, attr)
+				}
+			}
+			logger.LogAttrs(r.Context(), logLevel(rec.status), "access", attrs...)
+		})
+	}, nil
+}
+
+func logField(field string, r *http.Request, rec *responseRecorder, start time.Time, trusted []netip.Prefix) (slog.Attr, bool) {
+	switch field {
+	case FieldMethod:
+		return slog.String(field, r.Method), true
+	case FieldPath:
+		return slog.String(field, r.URL.Path), true
+	case FieldQuery:
+		return slog.String(field, r.URL.RawQuery), true
+	case FieldStatus:
+		return slog.Int(field, rec.status), true
+	case FieldBytes:
+		return slog.Int(field, rec.bytes), true
+	case FieldDuration:
+		return slog.Float64(field, float64(time.Since(start).Microseconds())/1000), true
+	case FieldClientIP:
+		return slog.String(field, clientIP(r, trusted)), true
+	case FieldUserAgent:
+		return slog.String(field, r.UserAgent()), true
+	case FieldReferer:
+		return slog.String(field, r.Referer()), true
+	case FieldProto:
+		return slog.String(field, r.Proto), true
+	}
+	return slog.Attr{}, false
+}
+
+func logLevel(status int) slog.Level {
+	switch {
+	case status >= http.StatusInternalServerError:
+		return slog.LevelError
+	case status >= http.StatusBadRequest:
+		return slog.LevelWarn
+	default:
+		return slog.LevelInfo
+	}
+}
+
+func skipPath(prefixes []string, path string) bool {
+	for _, prefix := range prefixes {
+		if path == prefix || strings.HasPrefix(path, strings.TrimSuffix(prefix, "/")+"/") {
+			return true
+		}
+	}
+	return false
+}
+
+// ParseTrustedProxies разбирает список адресов и подсетей (10.0.0.1, 10.0.0.0/8).
+func ParseTrustedProxies(list []string) ([]netip.Prefix, error) {
+	out := make([]netip.Prefix, 0, len(list))
+	for _, item := range list {
+		if strings.Contains(item, "/") {
+			prefix, err := netip.ParsePrefix(item)
+			if err != nil {
+				return nil, fmt.Errorf("invalid trusted proxy %q: %w", item, err)
+			}
+			out = append(out, prefix.Masked())
+			continue
+		}
+		addr, err := netip.ParseAddr(item)
+		if err != nil {
+			return nil, fmt.Errorf("invalid trusted proxy %q: %w", item, err)
+		}
+		addr = addr.Unmap()
+		out = append(out, netip.PrefixFrom(addr, addr.BitLen()))
+	}
+	return out, nil
+}
+
+func isTrusted(trusted []netip.Prefix, ip string) bool {
+	addr, err := netip.ParseAddr(ip)
+	if err != nil {
+		return false
+	}
+	addr = addr.Unmap()
+	for _, prefix := range trusted {
+		if prefix.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
+
+// clientIP доверяет заголовкам прокси, только если соединение пришло от доверенного прокси.
+// X-Forwarded-For читается справа налево: первый адрес не из списка прокси и есть клиент.
+func clientIP(r *http.Request, trusted []netip.Prefix) string {
+	remote, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		remote = r.RemoteAddr
+	}
+	if !isTrusted(trusted, remote) {
+		return remote
+	}
+
+	if xff := r.Header.Values("X-Forwarded-For"); len(xff) > 0 {
+		hops := strings.Split(strings.Join(xff, ","), ",")
+		for i := len(hops) - 1; i >= 0; i-- {
+			hop := strings.TrimSpace(hops[i])
+			if hop == "" {
+				continue
+			}
+			if !isTrusted(trusted, hop) || i == 0 {
+				return hop
+			}
+		}
+	}
+	if ip := strings.TrimSpace(r.Header.Get("X-Real-IP")); ip != "" {
+		return ip
+	}
+	return remote
 }
@@ -0,0 +1,91 @@
+package middlewares
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestClientIP(t *testing.T) {
+	trusted, err := ParseTrustedProxies([]string{"10.0.0.0/8", "::ffff:192.168.1.1"})
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+
+	for _, tc := range []struct {
+		name   string
+		remote string
+		xff    []string
+		realIP string
+		want   string
+	}{
+		{name: "no proxy headers", remote: "203.0.113.7:5000", want: "203.0.113.7"},
+		{name: "untrusted peer cannot spoof", remote: "203.0.113.7:5000", xff: []string{"1.2.3.4"}, realIP: "5.6.7.8", want: "203.0.113.7"},
+		{name: "trusted proxy", remote: "10.0.0.1:5000", xff: []string{"1.2.3.4"}, want: "1.2.3.4"},
+		// клиент мог сам прислать X-Forwarded-For, поэтому берётся первый справа не доверенный адрес
+		{name: "spoofed leftmost hop", remote: "10.0.0.1:5000", xff: []string{"6.6.6.6, 1.2.3.4, 10.0.0.2"}, want: "1.2.3.4"},
+		{name: "several headers", remote: "10.0.0.1:5000", xff: []string{"6.6.6.6", "1.2.3.4"}, want: "1.2.3.4"},
+		{name: "only proxies", remote: "10.0.0.1:5000", xff: []string{"10.0.0.3, 10.0.0.2"}, want: "10.0.0.3"},
+		{name: "empty hops", remote: "10.0.0.1:5000", xff: []string{"1.2.3.4, ,"}, want: "1.2.3.4"},
+		{name: "x-real-ip fallback", remote: "10.0.0.1:5000", realIP: " 1.2.3.4 ", want: "1.2.3.4"},
+		{name: "ipv4-mapped peer", remote: "[::ffff:192.168.1.1]:5000", xff: []string{"1.2.3.4"}, want: "1.2.3.4"},
+		{name: "remote without port", remote: "10.0.0.1", xff: []string{"1.2.3.4"}, want: "1.2.3.4"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, "/", nil)
+			r.RemoteAddr = tc.remote
+			for _, v := range tc.xff {
+				r.Header.Add("X-Forwarded-For", v)
+			}
+			if tc.realIP != "" {
+				r.Header.Set("X-Real-IP", tc.realIP)
+			}
+			if got := clientIP(r, trusted); got != tc.want {
+				t.Errorf("clientIP = %q, want %q", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestParseTrustedProxies(t *testing.T) {
+	for _, tc := range []struct {
+		item string
+		ok   bool
+	}{
+		{"10.0.0.1", true},
+		{"10.1.2.3/8", true},
+		{"2001:db8::/32", true},
+		{"localhost", false},
+		{"10.0.0.0/33", false},
+	} {
+		if _, err := ParseTrustedProxies([]string{tc.item}); (err == nil) != tc.ok {
+			t.Errorf("ParseTrustedProxies(%q): err = %v, want ok = %v", tc.item, err, tc.ok)
+		}
+	}
+}
+
+func TestNewLoggingMiddleware_Format(t *testing.T) {
+	if _, err := NewLoggingMiddleware(LoggingConfig{Format: "yaml"}); err == nil {
+		t.Errorf("expected unknown format to be rejected")
+	}
+
+	var out bytes.Buffer
+	mw, err := NewLoggingMiddleware(LoggingConfig{Format: "json", Output: &out, Fields: []string{FieldClientIP}})
+	if err != nil {
+		t.Fatalf("new: %v", err)
+	}
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	r.RemoteAddr = "203.0.113.7:5000"
+	r.Header.Set("X-Forwarded-For", "1.2.3.4")
+	mw(http.NotFoundHandler()).ServeHTTP(httptest.NewRecorder(), r)
+
+	var entry map[string]any
+	if err := json.Unmarshal(out.Bytes(), &entry); err != nil {
+		t.Fatalf("invalid JSON log line %q: %v", out.String(), err)
+	}
+	if entry[FieldClientIP] != "203.0.113.7" {
+		t.Errorf("expected connection address without trusted proxies, got %v", entry[FieldClientIP])
+	}
+}
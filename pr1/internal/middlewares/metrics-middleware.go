@@ -0,0 +1,37 @@
+package middlewares
+
+import (
+	"net/http"
+	"strings"
+	"time"
+
+	"example.com/helloapi/internal/metrics"
+)
+
+func NewMetricsMiddleware(m *metrics.HTTPMetrics, mux *http.ServeMux) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			route := routeLabel(mux, r)
+			start := time.Now()
+			m.Start(route)
+
+			rec := newResponseRecorder(w)
+			defer func() {
+				m.Finish(route, r.Method, rec.status, time.Since(start))
+			}()
+			next.ServeHTTP(rec, r)
+		})
+	}
+}
+
+// В лейбл попадает шаблон маршрута из ServeMux (/users/{id}), а не сырой путь.
+func routeLabel(mux *http.ServeMux, r *http.Request) string {
+	_, pattern := mux.Handler(r)
+	if pattern == "" {
+		return "unmatched"
+	}
+	if _, path, ok := strings.Cut(pattern, " "); ok {
+		return path
+	}
+	return pattern
+}
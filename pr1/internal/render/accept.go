@@ -0,0 +1,63 @@
+package render
+
+import (
+	"mime"
+	"strconv"
+	"strings"
+)
+
+type mediaRange struct {
+	typ     string
+	subtype string
+	q       float64
+}
+
+func parseAccept(header string) []mediaRange {
+	var ranges []mediaRange
+	for _, part := range strings.Split(header, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		mediaType, params, err := mime.ParseMediaType(part)
+		if err != nil {
+			continue
+		}
+		q := 1.0
+		if v, ok := params["q"]; ok {
+			parsed, err := strconv.ParseFloat(v, 64)
+			if err != nil || parsed < 0 || parsed > 1 {
+				parsed = 0
+			}
+			q = parsed
+		}
+		typ, subtype, ok := strings.Cut(mediaType, "/")
+		if !ok {
+			continue
+		}
+		ranges = append(ranges, mediaRange{typ: typ, subtype: subtype, q: q})
+	}
+	return ranges
+}
+
+// quality возвращает q самого специфичного диапазона, подходящего под mediaType,
+// и -1, если ни один диапазон не подходит.
+func quality(ranges []mediaRange, mediaType string) float64 {
+	typ, subtype, _ := strings.Cut(mediaType, "/")
+	best, bestSpecificity := -1.0, -1
+	for _, r := range ranges {
+		specificity := -1
+		switch {
+		case r.typ == typ && r.subtype == subtype:
+			specificity = 2
+		case r.typ == typ && r.subtype == "*":
+			specificity = 1
+		case r.typ == "*" && r.subtype == "*":
+			specificity = 0
+		}
+		if specificity > bestSpecificity {
+			best, bestSpecificity = r.q, specificity
+		}
+	}
+	return best
+}
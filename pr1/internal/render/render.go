@@ -0,0 +1,165 @@
+package render
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"reflect"
+	"strings"
+
+	"github.com/vmihailenco/msgpack/v5"
+)
+
+type Format struct {
+	ContentType string
+	MediaTypes  []string
+	Encode      func(w io.Writer, v any) error
+}
+
+var (
+	JSON = Format{
+		ContentType: "application/json",
+		MediaTypes:  []string{"application/json"},
+		Encode:      encodeJSON,
+	}
+	XML = Format{
+		ContentType: "application/xml; charset=utf-8",
+		MediaTypes:  []string{"application/xml", "text/xml"},
+		Encode:      encodeXML,
+	}
+	Text = Format{
+		ContentType: "text/plain; charset=utf-8",
+		MediaTypes:  []string{"text/plain"},
+		Encode:      encodeText,
+	}
+	MsgPack = Format{
+		ContentType: "application/msgpack",
+		MediaTypes:  []string{"application/msgpack", "application/x-msgpack", "application/vnd.msgpack"},
+		Encode:      encodeMsgPack,
+	}
+)
+
+// PlainTexter реализуют типы, у которых есть человекочитаемое текстовое представление.
+type PlainTexter interface {
+	PlainText() string
+}
+
+type Renderer struct {
+	formats []Format
+}
+
+func New(formats ...Format) *Renderer {
+	return &Renderer{formats: formats}
+}
+
+var Default = New(JSON, XML, Text, MsgPack)
+
+func Render(w http.ResponseWriter, r *http.Request, status int, v any) {
+	Default.Render(w, r, status, v)
+}
+
+func (rd *Renderer) Render(w http.ResponseWriter, r *http.Request, status int, v any) {
+	w.Header().Add("Vary", "Accept")
+
+	format, ok := rd.Negotiate(r.Header.Get("Accept"))
+	if !ok {
+		rd.notAcceptable(w)
+		return
+	}
+
+	var buf bytes.Buffer
+	if err := format.Encode(&buf, v); err != nil {
+		http.Error(w, "failed to encode response", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", format.ContentType)
+	w.WriteHeader(status)
+	w.Write(buf.Bytes())
+}
+
+// Negotiate выбирает формат с наибольшим q; при равных q побеждает тот,
+// что раньше в списке форматов рендерера. Пустой Accept означает */*.
+func (rd *Renderer) Negotiate(accept string) (Format, bool) {
+	if strings.TrimSpace(accept) == "" {
+		return rd.formats[0], true
+	}
+
+	ranges := parseAccept(accept)
+	var best Format
+	bestQ := 0.0
+	for _, f := range rd.formats {
+		for _, mediaType := range f.MediaTypes {
+			if q := quality(ranges, mediaType); q > bestQ {
+				best, bestQ = f, q
+			}
+		}
+	}
+	return best, bestQ > 0
+}
+
+func (rd *Renderer) notAcceptable(w http.ResponseWriter) {
+	var supported []string
+	for _, f := range rd.formats {
+		supported = append(supported, f.MediaTypes...)
+	}
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	w.WriteHeader(http.StatusNotAcceptable)
+	fmt.Fprintf(w, "not acceptable, supported media types: %s\n", strings.Join(supported, ", "))
+}
+
+func encodeJSON(w io.Writer, v any) error {
+	return json.NewEncoder(w).Encode(v)
+}
+
+func encodeXML(w io.Writer, v any) error {
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	enc := xml.NewEncoder(w)
+	if rv := reflect.ValueOf(v); rv.Kind() == reflect.Slice {
+		v = struct {
+			XMLName xml.Name `xml:"list"`
+			Items   any
+		}{Items: v}
+	}
+	if err := enc.Encode(v); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
+
+func encodeText(w io.Writer, v any) error {
+	if rv := reflect.ValueOf(v); rv.Kind() == reflect.Slice {
+		for i := 0; i < rv.Len(); i++ {
+			if _, err := io.WriteString(w, plainText(rv.Index(i).Interface())+"\n"); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	_, err := io.WriteString(w, plainText(v)+"\n")
+	return err
+}
+
+func plainText(v any) string {
+	switch t := v.(type) {
+	case PlainTexter:
+		return t.PlainText()
+	case fmt.Stringer:
+		return t.String()
+	case string:
+		return t
+	}
+	return fmt.Sprintf("%+v", v)
+}
+
+func encodeMsgPack(w io.Writer, v any) error {
+	enc := msgpack.NewEncoder(w)
+	enc.SetCustomStructTag("json")
+	return enc.Encode(v)
+}
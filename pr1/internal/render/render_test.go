@@ -0,0 +1,65 @@
+package render
+
+import (
+	"testing"
+)
+
+func TestParseAccept(t *testing.T) {
+	for _, tc := range []struct {
+		header string
+		want   []mediaRange
+	}{
+		{"application/json", []mediaRange{{"application", "json", 1}}},
+		{"text/html;q=0.8, application/*", []mediaRange{{"text", "html", 0.8}, {"application", "*", 1}}},
+		{"text/plain; q=0.25; charset=utf-8", []mediaRange{{"text", "plain", 0.25}}},
+		// недопустимый q считается нулевым, а не игнорируется
+		{"application/json;q=abc, text/plain;q=1.5, application/xml;q=-1", []mediaRange{
+			{"application", "json", 0}, {"text", "plain", 0}, {"application", "xml", 0},
+		}},
+		// битые диапазоны пропускаются
+		{"bogus, ;q=1, , */*;q=0.1", []mediaRange{{"*", "*", 0.1}}},
+		{"", nil},
+	} {
+		got := parseAccept(tc.header)
+		if len(got) != len(tc.want) {
+			t.Errorf("parseAccept(%q) = %v, want %v", tc.header, got, tc.want)
+			continue
+		}
+		for i := range got {
+			if got[i] != tc.want[i] {
+				t.Errorf("parseAccept(%q)[%d] = %v, want %v", tc.header, i, got[i], tc.want[i])
+			}
+		}
+	}
+}
+
+func TestRenderer_Negotiate(t *testing.T) {
+	for _, tc := range []struct {
+		accept string
+		want   string // ContentType выбранного формата, "" — 406
+	}{
+		{"", JSON.ContentType},
+		{"*/*", JSON.ContentType},
+		{"application/xml", XML.ContentType},
+		{"text/xml", XML.ContentType},
+		{"application/x-msgpack", MsgPack.ContentType},
+		{"text/plain;q=0.5, application/json;q=0.4", Text.ContentType},
+		// text/* подходит и под text/xml, а XML в списке раньше Text
+		{"text/*", XML.ContentType},
+		// при равных q побеждает формат, который раньше в списке
+		{"text/plain, application/xml", XML.ContentType},
+		// точный диапазон с q=0 запрещает формат, даже если */* его разрешает
+		{"application/json;q=0, */*;q=0.1", XML.ContentType},
+		{"image/png", ""},
+		{"application/json;q=0", ""},
+		{"application/json;q=abc", ""},
+	} {
+		got, ok := Default.Negotiate(tc.accept)
+		switch {
+		case tc.want == "" && ok:
+			t.Errorf("Negotiate(%q) = %s, want 406", tc.accept, got.ContentType)
+		case tc.want != "" && (!ok || got.ContentType != tc.want):
+			t.Errorf("Negotiate(%q) = %s, %v, want %s", tc.accept, got.ContentType, ok, tc.want)
+		}
+	}
+}
@@ -0,0 +1,143 @@
+package server
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"os/signal"
+	"syscall"
+	"time"
+)
+
+type Config struct {
+	Addr              string
+	ReadHeaderTimeout time.Duration
+	ReadTimeout       time.Duration
+	WriteTimeout      time.Duration
+	IdleTimeout       time.Duration
+	ShutdownTimeout   time.Duration
+
+	TLSCertFile string
+	TLSKeyFile  string
+	DevTLS      bool
+}
+
+func DefaultConfig() Config {
+	return Config{
+		Addr:              ":8080",
+		ReadHeaderTimeout: 5 * time.Second,
+		ReadTimeout:       15 * time.Second,
+		WriteTimeout:      30 * time.Second,
+		IdleTimeout:       120 * time.Second,
+		ShutdownTimeout:   20 * time.Second,
+	}
+}
+
+func (c Config) Validate() error {
+	if (c.TLSCertFile == "") != (c.TLSKeyFile == "") {
+		return errors.New("both TLS cert and key files must be set")
+	}
+	if c.DevTLS && c.TLSCertFile != "" {
+		return errors.New("dev TLS cannot be combined with TLS cert and key files")
+	}
+	if c.ShutdownTimeout <= 0 {
+		return errors.New("shutdown timeout must be positive")
+	}
+	return nil
+}
+
+func (c Config) TLSEnabled() bool {
+	return c.DevTLS || c.TLSCertFile != ""
+}
+
+func New(cfg Config, handler http.Handler) (*http.Server, error) {
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+
+	srv := &http.Server{
+		Addr:              cfg.Addr,
+		Handler:           handler,
+		ReadHeaderTimeout: cfg.ReadHeaderTimeout,
+		ReadTimeout:       cfg.ReadTimeout,
+		WriteTimeout:      cfg.WriteTimeout,
+		IdleTimeout:       cfg.IdleTimeout,
+	}
+
+	if cfg.TLSEnabled() {
+		srv.TLSConfig = &tls.Config{MinVersion: tls.VersionTLS12}
+	}
+	if cfg.DevTLS {
+		cert, err := SelfSignedCertificate(hostFromAddr(cfg.Addr))
+		if err != nil {
+			return nil, fmt.Errorf("generate dev certificate: %w", err)
+		}
+		srv.TLSConfig.Certificates = []tls.Certificate{cert}
+	}
+	return srv, nil
+}
+
+// Run запускает сервер и блокируется до SIGINT/SIGTERM, после чего даёт
+// обрабатываемым запросам завершиться в пределах ShutdownTimeout.
+func Run(cfg Config, handler http.Handler) error {
+	srv, err := New(cfg, handler)
+	if err != nil {
+		return err
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	errCh := make(chan error, 1)
+	go func() {
+		scheme := "http"
+		if cfg.TLSEnabled() {
+			scheme = "https"
+		}
+		log.Printf("Starting on %s (%s) ...", cfg.Addr, scheme)
+
+		var err error
+		switch {
+		case cfg.DevTLS:
+			err = srv.ListenAndServeTLS("", "")
+		case cfg.TLSCertFile != "":
+			err = srv.ListenAndServeTLS(cfg.TLSCertFile, cfg.TLSKeyFile)
+		default:
+			err = srv.ListenAndServe()
+		}
+		errCh <- err
+	}()
+
+	select {
+	case err := <-errCh:
+		return err
+	case <-ctx.Done():
+	}
+	stop()
+
+	log.Printf("Shutting down, waiting up to %s for in-flight requests ...", cfg.ShutdownTimeout)
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), cfg.ShutdownTimeout)
+	defer cancel()
+
+	if err := srv.Shutdown(shutdownCtx); err != nil {
+		srv.Close()
+		return fmt.Errorf("graceful shutdown: %w", err)
+	}
+	if err := <-errCh; err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	log.Println("Server stopped")
+	return nil
+}
+
+func hostFromAddr(addr string) string {
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil || host == "" {
+		return "localhost"
+	}
+	return host
+}
@@ -0,0 +1,143 @@
+package storage
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+type FileUserStore struct {
+	mu   sync.Mutex
+	path string
+	mem  *MemoryUserStore
+}
+
+func NewFileUserStore(path string) (*FileUserStore, error) {
+	s := &FileUserStore{path: path, mem: NewMemoryUserStore()}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return s, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("read users file: %w", err)
+	}
+
+	var users []User
+	if len(data) > 0 {
+		if err := json.Unmarshal(data, &users); err != nil {
+			return nil, fmt.Errorf("parse users file: %w", err)
+		}
+	}
+	for _, u := range users {
+		s.mem.put(u)
+	}
+	return s, nil
+}
+
+func (s *FileUserStore) List() ([]User, error) {
+	return s.mem.List()
+}
+
+func (s *FileUserStore) Get(id string) (User, error) {
+	return s.mem.Get(id)
+}
+
+func (s *FileUserStore) Create(name string) (User, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	u, err := s.mem.Create(name)
+	if err != nil {
+		return User{}, err
+	}
+	if err := s.persist(); err != nil {
+		s.mem.remove(u.ID)
+		return User{}, err
+	}
+	return u, nil
+}
+
+func (s *FileUserStore) Update(id, name string) (User, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	prev, err := s.mem.Get(id)
+	if err != nil {
+		return User{}, err
+	}
+	u, err := s.mem.Update(id, name)
+	if err != nil {
+		return User{}, err
+	}
+	if err := s.persist(); err != nil {
+		s.mem.put(prev)
+		return User{}, err
+	}
+	return u, nil
+}
+
+func (s *FileUserStore) Delete(id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	prev, err := s.mem.Get(id)
+	if err != nil {
+		return err
+	}
+	if err := s.mem.Delete(id); err != nil {
+		return err
+	}
+	if err := s.persist(); err != nil {
+		s.mem.put(prev)
+		return err
+	}
+	return nil
+}
+
+func (s *FileUserStore) persist() error {
+	users, err := s.mem.List()
+	if err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(users, "", "  ")
+	if err != nil {
+		return fmt.Errorf("encode users: %w", err)
+	}
+	return writeFileAtomic(s.path, data)
+}
+
+// Пишем во временный файл рядом с целевым и переименовываем его,
+// чтобы при сбое на диске всегда оставалась целая версия.
+func writeFileAtomic(path string, data []byte) error {
+	dir := filepath.Dir(path)
+	tmp, err := os.CreateTemp(dir, filepath.Base(path)+".tmp-*")
+	if err != nil {
+		return fmt.Errorf("create temp file: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("write temp file: %w", err)
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return fmt.Errorf("sync temp file: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("close temp file: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return fmt.Errorf("rename temp file: %w", err)
+	}
+
+	if d, err := os.Open(dir); err == nil {
+		_ = d.Sync()
+		d.Close()
+	}
+	return nil
+}
@@ -0,0 +1,108 @@
+package storage
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type MemoryUserStore struct {
+	mu    sync.RWMutex
+	users map[string]User
+}
+
+func NewMemoryUserStore() *MemoryUserStore {
+	return &MemoryUserStore{users: make(map[string]User)}
+}
+
+func (s *MemoryUserStore) List() ([]User, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	out := make([]User, 0, len(s.users))
+	for _, u := range s.users {
+		out = append(out, u)
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].CreatedAt.Equal(out[j].CreatedAt) {
+			return out[i].ID < out[j].ID
+		}
+		return out[i].CreatedAt.Before(out[j].CreatedAt)
+	})
+	return out, nil
+}
+
+func (s *MemoryUserStore) Get(id string) (User, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	u, ok := s.users[id]
+	if !ok {
+		return User{}, ErrNotFound
+	}
+	return u, nil
+}
+
+func (s *MemoryUserStore) Create(name string) (User, error) {
+	name, err := NormalizeName(name)
+	if err != nil {
+		return User{}, err
+	}
+
+	now := time.Now().UTC()
+	u := User{
+		ID:        uuid.NewString(),
+		Name:      name,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.users[u.ID] = u
+	return u, nil
+}
+
+func (s *MemoryUserStore) Update(id, name string) (User, error) {
+	name, err := NormalizeName(name)
+	if err != nil {
+		return User{}, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	u, ok := s.users[id]
+	if !ok {
+		return User{}, ErrNotFound
+	}
+	u.Name = name
+	u.UpdatedAt = time.Now().UTC()
+	s.users[id] = u
+	return u, nil
+}
+
+func (s *MemoryUserStore) Delete(id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.users[id]; !ok {
+		return ErrNotFound
+	}
+	delete(s.users, id)
+	return nil
+}
+
+func (s *MemoryUserStore) put(u User) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.users[u.ID] = u
+}
+
+func (s *MemoryUserStore) remove(id string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.users, id)
+}
@@ -0,0 +1,60 @@
+package storage
+
+import (
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+)
+
+var (
+	ErrNotFound    = errors.New("user not found")
+	ErrInvalidName = errors.New("invalid name")
+)
+
+const (
+	minNameLength = 2
+	maxNameLength = 64
+)
+
+type User struct {
+	XMLName   xml.Name  `json:"-" xml:"user"`
+	ID        string    `json:"id" xml:"id"`
+	Name      string    `json:"name" xml:"name"`
+	CreatedAt time.Time `json:"created_at" xml:"created_at"`
+	UpdatedAt time.Time `json:"updated_at" xml:"updated_at"`
+}
+
+func (u User) PlainText() string {
+	return u.ID + "\t" + u.Name
+}
+
+type UserStore interface {
+	List() ([]User, error)
+	Get(id string) (User, error)
+	Create(name string) (User, error)
+	Update(id, name string) (User, error)
+	Delete(id string) error
+}
+
+func NormalizeName(name string) (string, error) {
+	name = strings.Join(strings.Fields(name), " ")
+	length := utf8.RuneCountInString(name)
+	switch {
+	case length == 0:
+		return "", fmt.Errorf("%w: name is required", ErrInvalidName)
+	case length < minNameLength:
+		return "", fmt.Errorf("%w: name must be at least %d characters", ErrInvalidName, minNameLength)
+	case length > maxNameLength:
+		return "", fmt.Errorf("%w: name must be at most %d characters", ErrInvalidName, maxNameLength)
+	}
+	for _, r := range name {
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) && !strings.ContainsRune(" -'_.", r) {
+			return "", fmt.Errorf("%w: name contains forbidden character %q", ErrInvalidName, r)
+		}
+	}
+	return name, nil
+}
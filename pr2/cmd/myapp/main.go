@@ -1,7 +1,29 @@
 package main
 
-import "github.com/icestormerrr/myapp/internal/app"
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/icestormerrr/myapp/internal/app"
+	"github.com/icestormerrr/myapp/internal/config"
+)
 
 func main() {
-	app.Run()
+	cfg, err := config.Load(os.Args[1:])
+	if errors.Is(err, flag.ErrHelp) {
+		return
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
+	if cfg.PrintConfig {
+		_ = cfg.Print(os.Stdout)
+		return
+	}
+	if err := app.Run(cfg); err != nil {
+		os.Exit(1)
+	}
 }
@@ -1,29 +1,93 @@
 package app
 
 import (
+	"context"
+	"crypto/subtle"
+	"errors"
 	"net/http"
 	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
 
 	"github.com/icestormerrr/myapp/internal/app/handlers"
+	"github.com/icestormerrr/myapp/internal/config"
+	"github.com/icestormerrr/myapp/internal/flags"
 	"github.com/icestormerrr/myapp/utils"
 )
 
-func Run() {
-	mux := http.NewServeMux()
+const shutdownTimeout = 10 * time.Second
 
-	mux.HandleFunc("/", handlers.Root)
-	mux.HandleFunc("/ping", handlers.Ping)
-	mux.HandleFunc("/fail", handlers.Fail)
+func Run(cfg config.Config) error {
+	// ctx отменяется по SIGINT/SIGTERM и останавливает фоновые задачи
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	if err := utils.SetLevel(cfg.LogLevel); err != nil {
+		return err
+	}
+
+	exporter := newSpanExporter(cfg.Tracing)
+	utils.SetSpanExporter(exporter)
+	// спаны, накопленные экспортёром к моменту остановки, отправляются до выхода
+	defer func() {
+		ctx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+		defer cancel()
+		if err := exporter.Shutdown(ctx); err != nil {
+			utils.Warn(ctx, "span exporter shutdown", "error", err.Error())
+		}
+	}()
+
+	flagStore, err := flags.NewStore(cfg.FeatureFlags.File, cfg.FeatureFlags.UserHeader)
+	if err != nil {
+		return err
+	}
+	go flagStore.Watch(ctx, cfg.FeatureFlags.ReloadInterval.Duration)
 
-	handler := withRequestID(mux)
-	addr := getAddr()
+	router := NewRouter()
 
-	utils.LogInfo("Server is starting on " + addr)
+	router.HandleFunc("/", handlers.Root, http.MethodGet)
+	router.HandleFunc("/ping", handlers.Ping, http.MethodGet)
+	router.HandleFunc("/fail", handlers.Fail, http.MethodGet)
+	// /admin/* доступны только при включённом admin_api и всегда требуют токен
+	if cfg.Enabled("admin_api") {
+		router.Handle("/admin/log-level", withAdminToken(cfg.AdminToken, http.HandlerFunc(handlers.GetLogLevel)), http.MethodGet)
+		router.Handle("/admin/log-level", withAdminToken(cfg.AdminToken, http.HandlerFunc(handlers.SetLogLevel)), http.MethodPut)
+		router.Handle("/admin/flags", withAdminToken(cfg.AdminToken, handlers.Flags(flagStore)), http.MethodGet)
+	}
+
+	recovery := RecoveryConfig{CrashDir: cfg.CrashReports.Dir, MaxReports: cfg.CrashReports.MaxReports}
+	handler := withRequestID(withTracing(withRecovery(recovery, flags.Middleware(flagStore, router))))
 
-	if err := http.ListenAndServe(addr, handler); err != nil {
-		utils.LogError("server error: " + err.Error())
+	srv := &http.Server{
+		Addr:              cfg.Addr,
+		Handler:           handler,
+		ReadHeaderTimeout: cfg.Timeouts.ReadHeader.Duration,
+		ReadTimeout:       cfg.Timeouts.Read.Duration,
+		WriteTimeout:      cfg.Timeouts.Write.Duration,
+		IdleTimeout:       cfg.Timeouts.Idle.Duration,
 	}
 
+	utils.Info(ctx, "server is starting", "addr", cfg.Addr)
+
+	serveErr := make(chan error, 1)
+	go func() { serveErr <- srv.ListenAndServe() }()
+
+	select {
+	case err := <-serveErr:
+		utils.Error(ctx, "server error", "error", err.Error())
+		return err
+	case <-ctx.Done():
+	}
+
+	utils.Info(context.Background(), "shutting down")
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+	if err := srv.Shutdown(shutdownCtx); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		utils.Error(shutdownCtx, "shutdown error", "error", err.Error())
+		return err
+	}
+	return nil
 }
 
 func withRequestID(next http.Handler) http.Handler {
@@ -33,14 +97,78 @@ func withRequestID(next http.Handler) http.Handler {
 			id = utils.NewID16()
 		}
 		w.Header().Set("X-Request-Id", id)
-		next.ServeHTTP(w, r)
+		next.ServeHTTP(w, r.WithContext(utils.WithRequestID(r.Context(), id)))
 	})
 }
 
-func getAddr() string {
-	port := os.Getenv("APP_PORT")
-	if port == "" {
-		port = "8080"
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+func (sr *statusRecorder) WriteHeader(code int) {
+	sr.status = code
+	sr.ResponseWriter.WriteHeader(code)
+}
+
+// Unwrap даёт http.ResponseController доступ к Flush и прочим возможностям исходного writer.
+func (sr *statusRecorder) Unwrap() http.ResponseWriter {
+	return sr.ResponseWriter
+}
+
+func withTracing(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		if parent, err := utils.ParseTraceparent(r.Header.Get("traceparent")); err == nil {
+			parent.TraceState = utils.ParseTracestate(r.Header.Get("tracestate"))
+			ctx = utils.ContextWithRemoteSpanContext(ctx, parent)
+		}
+
+		// Имя уточняет Router по найденному маршруту, чтобы произвольные пути не плодили имён спанов.
+		ctx, span := utils.StartSpan(ctx, spanMethod(r.Method), utils.SpanKindServer)
+		defer span.Finish()
+		span.SetAttribute("http.method", r.Method)
+		span.SetAttribute("url.path", r.URL.Path)
+		span.SetAttribute("request_id", utils.RequestID(ctx))
+
+		rec := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+		next.ServeHTTP(rec, r.WithContext(ctx))
+
+		span.SetAttribute("http.status_code", rec.status)
+		if rec.status >= http.StatusInternalServerError {
+			span.SetStatus(utils.SpanStatusError, http.StatusText(rec.status))
+		}
+	})
+}
+
+// spanMethod сводит нестандартные методы к одному значению.
+func spanMethod(method string) string {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodPost, http.MethodPut, http.MethodPatch,
+		http.MethodDelete, http.MethodOptions, http.MethodConnect, http.MethodTrace:
+		return method
 	}
-	return ":" + port
+	return "HTTP"
+}
+
+func newSpanExporter(cfg config.Tracing) utils.SpanExporter {
+	switch cfg.Exporter {
+	case "stdout":
+		return utils.NewStdoutExporter()
+	case "otlp":
+		return utils.NewOTLPExporter(strings.TrimSuffix(cfg.OTLPEndpoint, "/")+"/v1/traces", cfg.ServiceName)
+	default:
+		return utils.NoopExporter{}
+	}
+}
+
+// withAdminToken требует заголовок Authorization: Bearer <token>; пустой токен не пропускает никого.
+func withAdminToken(token string, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if token == "" || subtle.ConstantTimeCompare([]byte(r.Header.Get("Authorization")), []byte("Bearer "+token)) != 1 {
+			utils.WriteErr(w, r, utils.ErrUnauthorized)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
 }
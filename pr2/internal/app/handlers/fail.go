@@ -1,12 +1,31 @@
 package handlers
 
 import (
+	"errors"
 	"net/http"
 
 	"github.com/icestormerrr/myapp/utils"
 )
 
+// Fail демонстрирует формат ошибок: ?kind=validation|not_found|internal|panic, по умолчанию bad_request.
 func Fail(w http.ResponseWriter, r *http.Request) {
 	utils.LogRequest(r)
-	utils.WriteErr(w, http.StatusBadRequest, "bad_request_example")
+
+	switch r.URL.Query().Get("kind") {
+	case "validation":
+		utils.WriteErr(w, r, utils.ErrValidation.
+			WithDetail("request has invalid fields").
+			WithViolations(
+				utils.Violation{Field: "email", Message: "must be a valid email address"},
+				utils.Violation{Field: "age", Message: "must be greater than or equal to 18"},
+			))
+	case "not_found":
+		utils.WriteErr(w, r, utils.ErrNotFound.WithDetail("example resource does not exist"))
+	case "internal":
+		utils.WriteErr(w, r, errors.New("example unexpected failure"))
+	case "panic":
+		panic("example panic")
+	default:
+		utils.WriteErr(w, r, utils.ErrBadRequest.WithDetail("bad_request_example"))
+	}
 }
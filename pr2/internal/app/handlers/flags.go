@@ -0,0 +1,27 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/icestormerrr/myapp/internal/flags"
+	"github.com/icestormerrr/myapp/utils"
+)
+
+type flagsResp struct {
+	flags.Snapshot
+	Evaluated map[string]bool `json:"evaluated"`
+}
+
+func Flags(store *flags.Store) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ev := flags.FromContext(r.Context())
+		if ev == nil {
+			// без flags.Middleware вычисляем флаги для запроса сами
+			ev = store.Evaluator(r)
+		}
+		utils.WriteJSON(w, http.StatusOK, flagsResp{
+			Snapshot:  store.Snapshot(),
+			Evaluated: ev.All(),
+		})
+	}
+}
@@ -0,0 +1,33 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/icestormerrr/myapp/utils"
+)
+
+type logLevelBody struct {
+	Level string `json:"level"`
+}
+
+func GetLogLevel(w http.ResponseWriter, r *http.Request) {
+	utils.WriteJSON(w, http.StatusOK, logLevelBody{Level: utils.Level()})
+}
+
+func SetLogLevel(w http.ResponseWriter, r *http.Request) {
+	var body logLevelBody
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		utils.WriteErr(w, r, utils.ErrBadRequest.WithDetail("invalid json").Wrap(err))
+		return
+	}
+	if err := utils.SetLevel(body.Level); err != nil {
+		utils.WriteErr(w, r, utils.ErrValidation.WithViolations(utils.Violation{
+			Field:   "level",
+			Message: "must be one of debug, info, warn, error",
+		}))
+		return
+	}
+	utils.Info(r.Context(), "log level changed", "level", utils.Level())
+	utils.WriteJSON(w, http.StatusOK, logLevelBody{Level: utils.Level()})
+}
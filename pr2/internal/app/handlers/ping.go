@@ -3,21 +3,40 @@ package handlers
 import (
 	"encoding/json"
 	"net/http"
+	"runtime"
 	"time"
 
+	"github.com/icestormerrr/myapp/internal/flags"
 	"github.com/icestormerrr/myapp/utils"
 )
 
+type pingDetails struct {
+	GoVersion  string `json:"go_version"`
+	Goroutines int    `json:"goroutines"`
+	RequestID  string `json:"request_id"`
+}
+
 type pingResp struct {
-	Status string `json:"status"`
-	Time   string `json:"time"`
+	Status  string       `json:"status"`
+	Time    string       `json:"time"`
+	Details *pingDetails `json:"details,omitempty"`
 }
 
 func Ping(w http.ResponseWriter, r *http.Request) {
 	utils.LogRequest(r)
-	w.Header().Set("Content-Type", "application/json; charset=utf-8")
-	_ = json.NewEncoder(w).Encode(pingResp{
+
+	resp := pingResp{
 		Status: "ok",
 		Time:   time.Now().UTC().Format(time.RFC3339),
-	})
+	}
+	if flags.Enabled(r.Context(), "ping_details") {
+		resp.Details = &pingDetails{
+			GoVersion:  runtime.Version(),
+			Goroutines: runtime.NumGoroutine(),
+			RequestID:  utils.RequestID(r.Context()),
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	_ = json.NewEncoder(w).Encode(resp)
 }
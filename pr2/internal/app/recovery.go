@@ -0,0 +1,176 @@
+package app
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"runtime"
+	"runtime/debug"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/icestormerrr/myapp/utils"
+)
+
+type RecoveryConfig struct {
+	CrashDir   string
+	MaxReports int
+}
+
+type crashProblem struct {
+	utils.Problem
+	ErrorID string `json:"error_id"`
+}
+
+type panicWriter struct {
+	http.ResponseWriter
+	wroteHeader bool
+}
+
+func (pw *panicWriter) WriteHeader(code int) {
+	pw.wroteHeader = true
+	pw.ResponseWriter.WriteHeader(code)
+}
+
+func (pw *panicWriter) Write(b []byte) (int, error) {
+	pw.wroteHeader = true
+	return pw.ResponseWriter.Write(b)
+}
+
+var crashMu sync.Mutex
+
+func withRecovery(cfg RecoveryConfig, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		pw := &panicWriter{ResponseWriter: w}
+		defer func() {
+			rec := recover()
+			if rec == nil {
+				return
+			}
+			// http.ErrAbortHandler — штатный способ оборвать ответ, его не перехватываем.
+			if err, ok := rec.(error); ok && errors.Is(err, http.ErrAbortHandler) {
+				panic(rec)
+			}
+
+			errorID := utils.NewID16()
+			stack := debug.Stack()
+			utils.Error(r.Context(), "panic recovered",
+				"error_id", errorID,
+				"panic", fmt.Sprint(rec),
+				"method", r.Method,
+				"path", r.URL.Path,
+				"remote_addr", r.RemoteAddr,
+				"user_agent", r.UserAgent(),
+				"stack", string(stack),
+			)
+
+			if cfg.CrashDir != "" {
+				if path, err := writeCrashReport(cfg, errorID, r, rec, stack); err != nil {
+					utils.Error(r.Context(), "failed to write crash report", "error_id", errorID, "error", err.Error())
+				} else {
+					utils.Info(r.Context(), "crash report written", "error_id", errorID, "path", path)
+				}
+			}
+
+			if pw.wroteHeader {
+				return
+			}
+			utils.WriteProblem(pw, crashProblem{
+				Problem: utils.NewProblem(r, utils.ErrInternal),
+				ErrorID: errorID,
+			}, http.StatusInternalServerError)
+		}()
+		next.ServeHTTP(pw, r)
+	})
+}
+
+var redactedHeaders = map[string]bool{
+	"Authorization": true,
+	"Cookie":        true,
+	"X-Api-Key":     true,
+}
+
+func writeCrashReport(cfg RecoveryConfig, errorID string, r *http.Request, rec any, stack []byte) (string, error) {
+	crashMu.Lock()
+	defer crashMu.Unlock()
+
+	if err := os.MkdirAll(cfg.CrashDir, 0o755); err != nil {
+		return "", err
+	}
+
+	var b strings.Builder
+	now := time.Now().UTC()
+	fmt.Fprintf(&b, "time: %s\n", now.Format(time.RFC3339Nano))
+	fmt.Fprintf(&b, "error_id: %s\n", errorID)
+	fmt.Fprintf(&b, "request_id: %s\n", utils.RequestID(r.Context()))
+	fmt.Fprintf(&b, "request: %s %s %s\n", r.Method, redactedURI(r.URL), r.Proto)
+	fmt.Fprintf(&b, "remote_addr: %s\n", r.RemoteAddr)
+	fmt.Fprintf(&b, "go_version: %s\n", runtime.Version())
+	b.WriteString("\nheaders:\n")
+	keys := make([]string, 0, len(r.Header))
+	for k := range r.Header {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		v := strings.Join(r.Header[k], ", ")
+		if redactedHeaders[k] {
+			v = "[REDACTED]"
+		}
+		fmt.Fprintf(&b, "  %s: %s\n", k, v)
+	}
+	fmt.Fprintf(&b, "\npanic: %v\n\n%s\n", rec, stack)
+	fmt.Fprintf(&b, "\ngoroutine dump:\n%s\n", goroutineDump())
+
+	name := fmt.Sprintf("crash-%s-%s.txt", now.Format("20060102T150405.000000000"), errorID)
+	path := filepath.Join(cfg.CrashDir, name)
+	if err := os.WriteFile(path, []byte(b.String()), 0o644); err != nil {
+		return "", err
+	}
+	rotateCrashReports(cfg)
+	return path, nil
+}
+
+func goroutineDump() []byte {
+	buf := make([]byte, 1<<16)
+	for {
+		n := runtime.Stack(buf, true)
+		if n < len(buf) || len(buf) >= 8<<20 {
+			return buf[:n]
+		}
+		buf = make([]byte, len(buf)*2)
+	}
+}
+
+// Имена отчётов начинаются с времени, поэтому сортировка по имени — это сортировка по возрасту.
+func rotateCrashReports(cfg RecoveryConfig) {
+	if cfg.MaxReports <= 0 {
+		return
+	}
+	files, err := filepath.Glob(filepath.Join(cfg.CrashDir, "crash-*.txt"))
+	if err != nil || len(files) <= cfg.MaxReports {
+		return
+	}
+	sort.Strings(files)
+	for _, f := range files[:len(files)-cfg.MaxReports] {
+		_ = os.Remove(f)
+	}
+}
+
+// redactedURI оставляет путь и имена параметров запроса, скрывая их значения:
+// в query могут оказаться токены и другие секреты.
+func redactedURI(u *url.URL) string {
+	if u.RawQuery == "" {
+		return u.EscapedPath()
+	}
+	query := u.Query()
+	for k := range query {
+		query[k] = []string{"REDACTED"}
+	}
+	return u.EscapedPath() + "?" + query.Encode()
+}
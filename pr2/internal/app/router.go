@@ -0,0 +1,92 @@
+package app
+
+import (
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/icestormerrr/myapp/utils"
+)
+
+// Router сопоставляет точный путь и метод. HEAD для GET-маршрутов и OPTIONS
+// формируются автоматически, на неизвестный метод отвечает 405 с заголовком Allow.
+type Router struct {
+	routes map[string]map[string]http.Handler
+}
+
+func NewRouter() *Router {
+	return &Router{routes: make(map[string]map[string]http.Handler)}
+}
+
+func (rt *Router) Handle(path string, h http.Handler, methods ...string) {
+	byMethod, ok := rt.routes[path]
+	if !ok {
+		byMethod = make(map[string]http.Handler)
+		rt.routes[path] = byMethod
+	}
+	for _, m := range methods {
+		byMethod[strings.ToUpper(m)] = h
+	}
+}
+
+func (rt *Router) HandleFunc(path string, h http.HandlerFunc, methods ...string) {
+	rt.Handle(path, h, methods...)
+}
+
+func (rt *Router) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	byMethod, ok := rt.routes[r.URL.Path]
+	if !ok {
+		utils.WriteErr(w, r, utils.ErrNotFound.WithDetail("no route for %s", r.URL.Path))
+		return
+	}
+	if span := utils.SpanFromContext(r.Context()); span != nil {
+		span.SetName(spanMethod(r.Method) + " " + r.URL.Path)
+		span.SetAttribute("http.route", r.URL.Path)
+	}
+
+	if h, ok := byMethod[r.Method]; ok {
+		h.ServeHTTP(w, r)
+		return
+	}
+
+	switch r.Method {
+	case http.MethodHead:
+		if h, ok := byMethod[http.MethodGet]; ok {
+			h.ServeHTTP(&headWriter{ResponseWriter: w}, r)
+			return
+		}
+	case http.MethodOptions:
+		w.Header().Set("Allow", allowHeader(byMethod))
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	w.Header().Set("Allow", allowHeader(byMethod))
+	utils.WriteErr(w, r, utils.ErrMethodNotAllowed.WithDetail("method %s is not allowed for %s", r.Method, r.URL.Path))
+}
+
+func allowHeader(byMethod map[string]http.Handler) string {
+	methods := make([]string, 0, len(byMethod)+2)
+	for m := range byMethod {
+		methods = append(methods, m)
+	}
+	if _, ok := byMethod[http.MethodGet]; ok {
+		if _, ok := byMethod[http.MethodHead]; !ok {
+			methods = append(methods, http.MethodHead)
+		}
+	}
+	if _, ok := byMethod[http.MethodOptions]; !ok {
+		methods = append(methods, http.MethodOptions)
+	}
+	sort.Strings(methods)
+	return strings.Join(methods, ", ")
+}
+
+// headWriter отбрасывает тело ответа, оставляя статус и заголовки.
+type headWriter struct {
+	http.ResponseWriter
+}
+
+func (hw *headWriter) Write(b []byte) (int, error) {
+	return len(b), nil
+}
@@ -0,0 +1,238 @@
+package config
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+type Duration struct {
+	time.Duration
+}
+
+func (d Duration) MarshalJSON() ([]byte, error) {
+	return json.Marshal(d.String())
+}
+
+func (d *Duration) UnmarshalJSON(b []byte) error {
+	var s string
+	if err := json.Unmarshal(b, &s); err != nil {
+		return fmt.Errorf("duration must be a string like \"5s\": %w", err)
+	}
+	return d.parse(s)
+}
+
+func (d *Duration) UnmarshalYAML(node *yaml.Node) error {
+	return d.parse(node.Value)
+}
+
+func (d *Duration) parse(s string) error {
+	v, err := time.ParseDuration(strings.TrimSpace(s))
+	if err != nil {
+		return err
+	}
+	d.Duration = v
+	return nil
+}
+
+type Timeouts struct {
+	ReadHeader Duration `json:"read_header" yaml:"read_header"`
+	Read       Duration `json:"read" yaml:"read"`
+	Write      Duration `json:"write" yaml:"write"`
+	Idle       Duration `json:"idle" yaml:"idle"`
+}
+
+type Tracing struct {
+	Exporter     string `json:"exporter" yaml:"exporter"`
+	OTLPEndpoint string `json:"otlp_endpoint" yaml:"otlp_endpoint" secret:"url"`
+	ServiceName  string `json:"service_name" yaml:"service_name"`
+}
+
+type CrashReports struct {
+	Dir        string `json:"dir" yaml:"dir"`
+	MaxReports int    `json:"max_reports" yaml:"max_reports"`
+}
+
+type FeatureFlags struct {
+	File           string   `json:"file" yaml:"file"`
+	ReloadInterval Duration `json:"reload_interval" yaml:"reload_interval"`
+	UserHeader     string   `json:"user_header" yaml:"user_header"`
+}
+
+type Config struct {
+	Addr         string          `json:"addr" yaml:"addr"`
+	LogLevel     string          `json:"log_level" yaml:"log_level"`
+	Timeouts     Timeouts        `json:"timeouts" yaml:"timeouts"`
+	Tracing      Tracing         `json:"tracing" yaml:"tracing"`
+	CrashReports CrashReports    `json:"crash_reports" yaml:"crash_reports"`
+	FeatureFlags FeatureFlags    `json:"feature_flags" yaml:"feature_flags"`
+	Features     map[string]bool `json:"features" yaml:"features"`
+	AdminToken   string          `json:"admin_token" yaml:"admin_token" secret:"true"`
+
+	PrintConfig bool `json:"-" yaml:"-"`
+}
+
+// KnownFeatures — переключатели, которые понимает приложение, и их значения по умолчанию.
+var KnownFeatures = map[string]bool{
+	// admin_api открывает /admin/*; включается только вместе с admin_token
+	"admin_api": false,
+}
+
+func Default() Config {
+	features := make(map[string]bool, len(KnownFeatures))
+	for name, enabled := range KnownFeatures {
+		features[name] = enabled
+	}
+	return Config{
+		Addr:     ":8080",
+		LogLevel: "info",
+		Timeouts: Timeouts{
+			ReadHeader: Duration{5 * time.Second},
+			Read:       Duration{15 * time.Second},
+			Write:      Duration{30 * time.Second},
+			Idle:       Duration{120 * time.Second},
+		},
+		Tracing: Tracing{
+			Exporter:     "none",
+			OTLPEndpoint: "http://localhost:4318",
+			ServiceName:  "myapp",
+		},
+		CrashReports: CrashReports{MaxReports: 20},
+		FeatureFlags: FeatureFlags{
+			ReloadInterval: Duration{2 * time.Second},
+			UserHeader:     "X-User-Id",
+		},
+		Features: features,
+	}
+}
+
+func (c Config) Enabled(feature string) bool {
+	return c.Features[feature]
+}
+
+// Load собирает конфигурацию по возрастанию приоритета:
+// значения по умолчанию, файл (YAML или JSON), переменные окружения, флаги командной строки.
+func Load(args []string) (Config, error) {
+	cfg := Default()
+
+	fl, err := parseFlags(args)
+	if err != nil {
+		return cfg, err
+	}
+
+	path := fl.configPath
+	if path == "" {
+		path = os.Getenv("APP_CONFIG")
+	}
+	if path != "" {
+		if err := loadFile(&cfg, path); err != nil {
+			return cfg, err
+		}
+	}
+
+	if err := applyEnv(&cfg, os.LookupEnv, os.Environ()); err != nil {
+		return cfg, err
+	}
+	fl.apply(&cfg)
+
+	if err := cfg.Validate(); err != nil {
+		return cfg, err
+	}
+	return cfg, nil
+}
+
+func loadFile(cfg *Config, path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("config file: %w", err)
+	}
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".json":
+		dec := json.NewDecoder(strings.NewReader(string(data)))
+		dec.DisallowUnknownFields()
+		err = dec.Decode(cfg)
+	case ".yaml", ".yml":
+		dec := yaml.NewDecoder(strings.NewReader(string(data)))
+		dec.KnownFields(true)
+		err = dec.Decode(cfg)
+	default:
+		return fmt.Errorf("config file %s: unsupported extension, use .yaml, .yml or .json", path)
+	}
+	if err != nil {
+		return fmt.Errorf("config file %s: %w", path, err)
+	}
+	cfg.LogLevel = strings.ToLower(cfg.LogLevel)
+	return nil
+}
+
+var validLogLevels = []string{"debug", "info", "warn", "error"}
+var validExporters = []string{"none", "stdout", "otlp"}
+
+func (c Config) Validate() error {
+	var errs []error
+	fail := func(field, format string, args ...any) {
+		errs = append(errs, fmt.Errorf("config: %s: %s", field, fmt.Sprintf(format, args...)))
+	}
+
+	if _, port, err := net.SplitHostPort(c.Addr); err != nil || port == "" {
+		fail("addr", "%q is not a valid host:port", c.Addr)
+	}
+	if !contains(validLogLevels, c.LogLevel) {
+		fail("log_level", "%q is not one of %s", c.LogLevel, strings.Join(validLogLevels, ", "))
+	}
+
+	for name, d := range map[string]Duration{
+		"timeouts.read_header": c.Timeouts.ReadHeader,
+		"timeouts.read":        c.Timeouts.Read,
+		"timeouts.write":       c.Timeouts.Write,
+		"timeouts.idle":        c.Timeouts.Idle,
+	} {
+		if d.Duration < 0 {
+			fail(name, "must not be negative, got %s", d)
+		}
+	}
+
+	if !contains(validExporters, c.Tracing.Exporter) {
+		fail("tracing.exporter", "%q is not one of %s", c.Tracing.Exporter, strings.Join(validExporters, ", "))
+	}
+	if c.Tracing.Exporter == "otlp" {
+		if u, err := url.Parse(c.Tracing.OTLPEndpoint); err != nil || u.Scheme == "" || u.Host == "" {
+			fail("tracing.otlp_endpoint", "%q is not an absolute URL", c.Tracing.OTLPEndpoint)
+		}
+	}
+	if c.FeatureFlags.File != "" && c.FeatureFlags.ReloadInterval.Duration <= 0 {
+		fail("feature_flags.reload_interval", "must be positive, got %s", c.FeatureFlags.ReloadInterval)
+	}
+	if c.CrashReports.MaxReports < 0 {
+		fail("crash_reports.max_reports", "must not be negative, got %d", c.CrashReports.MaxReports)
+	}
+	for name := range c.Features {
+		if _, ok := KnownFeatures[name]; !ok {
+			fail("features."+name, "unknown feature")
+		}
+	}
+	if c.Enabled("admin_api") && c.AdminToken == "" {
+		fail("admin_token", "is required when features.admin_api is enabled")
+	}
+
+	sort.Slice(errs, func(i, j int) bool { return errs[i].Error() < errs[j].Error() })
+	return errors.Join(errs...)
+}
+
+func contains(list []string, v string) bool {
+	for _, item := range list {
+		if item == v {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,42 @@
+package config
+
+import (
+	"encoding/json"
+	"io"
+	"net/url"
+	"reflect"
+)
+
+const redacted = "[REDACTED]"
+
+// Redacted возвращает копию конфигурации со скрытыми секретами, включая вложенные секции:
+// непустые поля с тегом secret:"true" заменяются целиком, а в полях с secret:"url"
+// скрывается только пароль из userinfo адреса (user:xxxxx@host).
+func (c Config) Redacted() Config {
+	redact(reflect.ValueOf(&c).Elem())
+	return c
+}
+
+func redact(v reflect.Value) {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		f := v.Field(i)
+		switch tag := t.Field(i).Tag.Get("secret"); {
+		case f.Kind() == reflect.Struct:
+			redact(f)
+		case f.Kind() != reflect.String || f.String() == "":
+		case tag == "true":
+			f.SetString(redacted)
+		case tag == "url":
+			if u, err := url.Parse(f.String()); err == nil {
+				f.SetString(u.Redacted())
+			}
+		}
+	}
+}
+
+func (c Config) Print(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(c.Redacted())
+}
@@ -0,0 +1,170 @@
+package config
+
+import (
+	"flag"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type featureFlags map[string]bool
+
+func (f featureFlags) String() string { return "" }
+
+func (f featureFlags) Set(s string) error {
+	name, value, ok := strings.Cut(s, "=")
+	enabled := true
+	if ok {
+		v, err := strconv.ParseBool(value)
+		if err != nil {
+			return fmt.Errorf("feature %q: %w", name, err)
+		}
+		enabled = v
+	}
+	f[strings.TrimSpace(name)] = enabled
+	return nil
+}
+
+type flagValues struct {
+	set        map[string]bool
+	configPath string
+
+	addr         string
+	logLevel     string
+	readHeader   time.Duration
+	read         time.Duration
+	write        time.Duration
+	idle         time.Duration
+	exporter     string
+	otlpEndpoint string
+	flagsFile    string
+	features     featureFlags
+	printConfig  bool
+}
+
+func parseFlags(args []string) (*flagValues, error) {
+	fl := &flagValues{set: make(map[string]bool), features: make(featureFlags)}
+
+	fs := flag.NewFlagSet("myapp", flag.ContinueOnError)
+	fs.StringVar(&fl.configPath, "config", "", "path to a YAML or JSON config file (env APP_CONFIG)")
+	fs.StringVar(&fl.addr, "addr", "", "listen address, e.g. :8080 (env APP_ADDR or APP_PORT)")
+	fs.StringVar(&fl.logLevel, "log-level", "", "debug, info, warn or error (env LOG_LEVEL)")
+	fs.DurationVar(&fl.readHeader, "read-header-timeout", 0, "HTTP read header timeout (env HTTP_READ_HEADER_TIMEOUT)")
+	fs.DurationVar(&fl.read, "read-timeout", 0, "HTTP read timeout (env HTTP_READ_TIMEOUT)")
+	fs.DurationVar(&fl.write, "write-timeout", 0, "HTTP write timeout (env HTTP_WRITE_TIMEOUT)")
+	fs.DurationVar(&fl.idle, "idle-timeout", 0, "HTTP idle timeout (env HTTP_IDLE_TIMEOUT)")
+	fs.StringVar(&fl.exporter, "trace-exporter", "", "none, stdout or otlp (env TRACE_EXPORTER)")
+	fs.StringVar(&fl.otlpEndpoint, "otlp-endpoint", "", "OTLP/HTTP collector URL (env OTEL_EXPORTER_OTLP_ENDPOINT)")
+	fs.StringVar(&fl.flagsFile, "flags-file", "", "path to a YAML or JSON feature flags file (env FLAGS_FILE)")
+	fs.Var(fl.features, "feature", "toggle a feature, name=true|false; may be repeated (env FEATURE_<NAME>)")
+	fs.BoolVar(&fl.printConfig, "print-config", false, "print the effective config with secrets redacted and exit")
+
+	if err := fs.Parse(args); err != nil {
+		return nil, err
+	}
+	fs.Visit(func(f *flag.Flag) { fl.set[f.Name] = true })
+	return fl, nil
+}
+
+func (fl *flagValues) apply(cfg *Config) {
+	if fl.set["addr"] {
+		cfg.Addr = fl.addr
+	}
+	if fl.set["log-level"] {
+		cfg.LogLevel = strings.ToLower(fl.logLevel)
+	}
+	if fl.set["read-header-timeout"] {
+		cfg.Timeouts.ReadHeader = Duration{fl.readHeader}
+	}
+	if fl.set["read-timeout"] {
+		cfg.Timeouts.Read = Duration{fl.read}
+	}
+	if fl.set["write-timeout"] {
+		cfg.Timeouts.Write = Duration{fl.write}
+	}
+	if fl.set["idle-timeout"] {
+		cfg.Timeouts.Idle = Duration{fl.idle}
+	}
+	if fl.set["trace-exporter"] {
+		cfg.Tracing.Exporter = fl.exporter
+	}
+	if fl.set["otlp-endpoint"] {
+		cfg.Tracing.OTLPEndpoint = fl.otlpEndpoint
+	}
+	if fl.set["flags-file"] {
+		cfg.FeatureFlags.File = fl.flagsFile
+	}
+	for name, enabled := range fl.features {
+		setFeature(cfg, name, enabled)
+	}
+	cfg.PrintConfig = fl.printConfig
+}
+
+func applyEnv(cfg *Config, lookup func(string) (string, bool), environ []string) error {
+	str := func(key string, dst *string) {
+		if v, ok := lookup(key); ok && v != "" {
+			*dst = v
+		}
+	}
+	var errs []string
+	dur := func(key string, dst *Duration) {
+		if v, ok := lookup(key); ok && v != "" {
+			if err := dst.parse(v); err != nil {
+				errs = append(errs, fmt.Sprintf("%s: %v", key, err))
+			}
+		}
+	}
+
+	if port, ok := lookup("APP_PORT"); ok && port != "" {
+		cfg.Addr = ":" + port
+	}
+	str("APP_ADDR", &cfg.Addr)
+	str("LOG_LEVEL", &cfg.LogLevel)
+	cfg.LogLevel = strings.ToLower(cfg.LogLevel)
+	dur("HTTP_READ_HEADER_TIMEOUT", &cfg.Timeouts.ReadHeader)
+	dur("HTTP_READ_TIMEOUT", &cfg.Timeouts.Read)
+	dur("HTTP_WRITE_TIMEOUT", &cfg.Timeouts.Write)
+	dur("HTTP_IDLE_TIMEOUT", &cfg.Timeouts.Idle)
+	str("TRACE_EXPORTER", &cfg.Tracing.Exporter)
+	str("OTEL_EXPORTER_OTLP_ENDPOINT", &cfg.Tracing.OTLPEndpoint)
+	str("OTEL_SERVICE_NAME", &cfg.Tracing.ServiceName)
+	str("CRASH_REPORT_DIR", &cfg.CrashReports.Dir)
+	str("ADMIN_TOKEN", &cfg.AdminToken)
+	str("FLAGS_FILE", &cfg.FeatureFlags.File)
+	dur("FLAGS_RELOAD_INTERVAL", &cfg.FeatureFlags.ReloadInterval)
+	str("FLAGS_USER_HEADER", &cfg.FeatureFlags.UserHeader)
+
+	if v, ok := lookup("CRASH_REPORT_MAX"); ok && v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil {
+			errs = append(errs, fmt.Sprintf("CRASH_REPORT_MAX: %q is not an integer", v))
+		}
+		cfg.CrashReports.MaxReports = n
+	}
+
+	for _, kv := range environ {
+		key, value, _ := strings.Cut(kv, "=")
+		if !strings.HasPrefix(key, "FEATURE_") {
+			continue
+		}
+		enabled, err := strconv.ParseBool(value)
+		if err != nil {
+			errs = append(errs, fmt.Sprintf("%s: %q is not a boolean", key, value))
+			continue
+		}
+		setFeature(cfg, strings.ToLower(strings.TrimPrefix(key, "FEATURE_")), enabled)
+	}
+
+	if len(errs) > 0 {
+		return fmt.Errorf("config: environment: %s", strings.Join(errs, "; "))
+	}
+	return nil
+}
+
+func setFeature(cfg *Config, name string, enabled bool) {
+	if cfg.Features == nil {
+		cfg.Features = make(map[string]bool)
+	}
+	cfg.Features[name] = enabled
+}
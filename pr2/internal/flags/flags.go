@@ -0,0 +1,287 @@
+package flags
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/icestormerrr/myapp/utils"
+	"gopkg.in/yaml.v3"
+)
+
+type Target struct {
+	Attribute string   `json:"attribute" yaml:"attribute"`
+	Values    []string `json:"values" yaml:"values"`
+}
+
+// Flag включается, если Enabled и при этом запрос попал в один из Targets,
+// либо (если заданы проценты) его ключ попал в первые Percentage процентов.
+// Флаг без Targets и Percentage — обычный булев переключатель.
+type Flag struct {
+	Enabled    bool     `json:"enabled" yaml:"enabled"`
+	Percentage *float64 `json:"percentage,omitempty" yaml:"percentage,omitempty"`
+	Targets    []Target `json:"targets,omitempty" yaml:"targets,omitempty"`
+}
+
+type fileFormat struct {
+	Flags map[string]Flag `json:"flags" yaml:"flags"`
+}
+
+type Store struct {
+	path       string
+	userHeader string
+
+	mu       sync.RWMutex
+	flags    map[string]Flag
+	modTime  time.Time
+	size     int64
+	loadedAt time.Time
+}
+
+func NewStore(path, userHeader string) (*Store, error) {
+	if userHeader == "" {
+		userHeader = "X-User-Id"
+	}
+	s := &Store{path: path, userHeader: userHeader, flags: map[string]Flag{}}
+	if path == "" {
+		return s, nil
+	}
+	if err := s.reload(); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+func (s *Store) reload() error {
+	info, err := os.Stat(s.path)
+	if err != nil {
+		return fmt.Errorf("flags file: %w", err)
+	}
+	data, err := os.ReadFile(s.path)
+	if err != nil {
+		return fmt.Errorf("flags file: %w", err)
+	}
+
+	var f fileFormat
+	switch strings.ToLower(filepath.Ext(s.path)) {
+	case ".json":
+		err = json.Unmarshal(data, &f)
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(data, &f)
+	default:
+		return fmt.Errorf("flags file %s: unsupported extension, use .yaml, .yml or .json", s.path)
+	}
+	if err != nil {
+		return fmt.Errorf("flags file %s: %w", s.path, err)
+	}
+	if err := validate(f.Flags); err != nil {
+		return fmt.Errorf("flags file %s: %w", s.path, err)
+	}
+	if f.Flags == nil {
+		f.Flags = map[string]Flag{}
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.flags = f.Flags
+	s.modTime = info.ModTime()
+	s.size = info.Size()
+	s.loadedAt = time.Now().UTC()
+	return nil
+}
+
+func validate(flags map[string]Flag) error {
+	var errs []error
+	for name, f := range flags {
+		if f.Percentage != nil && (*f.Percentage < 0 || *f.Percentage > 100) {
+			errs = append(errs, fmt.Errorf("flag %q: percentage must be between 0 and 100", name))
+		}
+		for _, t := range f.Targets {
+			if t.Attribute == "" || len(t.Values) == 0 {
+				errs = append(errs, fmt.Errorf("flag %q: target needs an attribute and at least one value", name))
+			}
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Watch опрашивает файл и перечитывает его при изменении времени модификации или размера.
+// Если новый файл не читается, остаются действовать прежние флаги.
+func (s *Store) Watch(ctx context.Context, interval time.Duration) {
+	if s.path == "" || interval <= 0 {
+		return
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		info, err := os.Stat(s.path)
+		if err != nil {
+			utils.Warn(ctx, "flags file is not accessible", "path", s.path, "error", err.Error())
+			continue
+		}
+		s.mu.RLock()
+		changed := !info.ModTime().Equal(s.modTime) || info.Size() != s.size
+		s.mu.RUnlock()
+		if !changed {
+			continue
+		}
+
+		if err := s.reload(); err != nil {
+			utils.Error(ctx, "failed to reload flags, keeping previous version", "error", err.Error())
+			continue
+		}
+		utils.Info(ctx, "flags reloaded", "path", s.path)
+	}
+}
+
+type Snapshot struct {
+	Source   string          `json:"source"`
+	LoadedAt time.Time       `json:"loaded_at"`
+	Flags    map[string]Flag `json:"flags"`
+}
+
+func (s *Store) Snapshot() Snapshot {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	flags := make(map[string]Flag, len(s.flags))
+	for k, v := range s.flags {
+		flags[k] = v
+	}
+	return Snapshot{Source: s.path, LoadedAt: s.loadedAt, Flags: flags}
+}
+
+func (s *Store) Names() []string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	names := make([]string, 0, len(s.flags))
+	for name := range s.flags {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func (s *Store) lookup(name string) (Flag, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	f, ok := s.flags[name]
+	return f, ok
+}
+
+// Evaluator вычисляет флаги для одного запроса и запоминает результат,
+// чтобы в рамках запроса флаг не «переключался» после перезагрузки файла.
+type Evaluator struct {
+	store *Store
+	r     *http.Request
+
+	mu    sync.Mutex
+	cache map[string]bool
+}
+
+func (s *Store) Evaluator(r *http.Request) *Evaluator {
+	return &Evaluator{store: s, r: r, cache: make(map[string]bool)}
+}
+
+func (e *Evaluator) Enabled(name string) bool {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if v, ok := e.cache[name]; ok {
+		return v
+	}
+	f, ok := e.store.lookup(name)
+	v := ok && e.evaluate(name, f)
+	e.cache[name] = v
+	return v
+}
+
+func (e *Evaluator) All() map[string]bool {
+	out := make(map[string]bool)
+	for _, name := range e.store.Names() {
+		out[name] = e.Enabled(name)
+	}
+	return out
+}
+
+func (e *Evaluator) evaluate(name string, f Flag) bool {
+	if !f.Enabled {
+		return false
+	}
+	for _, t := range f.Targets {
+		value := e.attribute(t.Attribute)
+		for _, v := range t.Values {
+			if value != "" && value == v {
+				return true
+			}
+		}
+	}
+	if f.Percentage != nil {
+		return bucket(name, e.key()) < *f.Percentage
+	}
+	return len(f.Targets) == 0
+}
+
+// key — пользователь из заголовка, иначе request ID; один и тот же пользователь
+// всегда попадает в одну и ту же корзину процентного раската.
+func (e *Evaluator) key() string {
+	if user := e.r.Header.Get(e.store.userHeader); user != "" {
+		return user
+	}
+	return utils.RequestID(e.r.Context())
+}
+
+func (e *Evaluator) attribute(name string) string {
+	if header, ok := strings.CutPrefix(name, "header:"); ok {
+		return e.r.Header.Get(header)
+	}
+	switch name {
+	case "user":
+		return e.r.Header.Get(e.store.userHeader)
+	case "request_id":
+		return utils.RequestID(e.r.Context())
+	case "method":
+		return e.r.Method
+	case "path":
+		return e.r.URL.Path
+	}
+	return ""
+}
+
+func bucket(flag, key string) float64 {
+	h := fnv.New32a()
+	h.Write([]byte(flag + ":" + key))
+	return float64(h.Sum32()%10000) / 100
+}
+
+type ctxKey struct{}
+
+func Middleware(store *Store, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ev := store.Evaluator(r)
+		next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), ctxKey{}, ev)))
+	})
+}
+
+func FromContext(ctx context.Context) *Evaluator {
+	ev, _ := ctx.Value(ctxKey{}).(*Evaluator)
+	return ev
+}
+
+func Enabled(ctx context.Context, name string) bool {
+	ev := FromContext(ctx)
+	return ev != nil && ev.Enabled(name)
+}
@@ -0,0 +1,86 @@
+package utils
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+)
+
+type Violation struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+type AppError struct {
+	Code       string
+	Status     int
+	Title      string
+	Detail     string
+	Violations []Violation
+	Err        error
+}
+
+func (e *AppError) Error() string {
+	msg := e.Code
+	if e.Detail != "" {
+		msg += ": " + e.Detail
+	}
+	if e.Err != nil {
+		msg += ": " + e.Err.Error()
+	}
+	return msg
+}
+
+func (e *AppError) Unwrap() error { return e.Err }
+
+// Is сравнивает ошибки по коду, чтобы errors.Is(err, utils.ErrNotFound) работал для копий из каталога.
+func (e *AppError) Is(target error) bool {
+	t, ok := target.(*AppError)
+	return ok && t.Code == e.Code
+}
+
+func (e *AppError) WithDetail(format string, args ...any) *AppError {
+	cp := *e
+	cp.Detail = fmt.Sprintf(format, args...)
+	return &cp
+}
+
+func (e *AppError) WithViolations(v ...Violation) *AppError {
+	cp := *e
+	cp.Violations = append(append([]Violation(nil), e.Violations...), v...)
+	return &cp
+}
+
+func (e *AppError) Wrap(err error) *AppError {
+	cp := *e
+	cp.Err = err
+	return &cp
+}
+
+func newAppError(code string, status int, title string) *AppError {
+	e := &AppError{Code: code, Status: status, Title: title}
+	ErrorCatalog[code] = e
+	return e
+}
+
+// ErrorCatalog содержит все коды ошибок, которые может вернуть API. Коды стабильны,
+// клиенты могут на них опираться; title и detail — только для людей.
+var ErrorCatalog = map[string]*AppError{}
+
+var (
+	ErrBadRequest       = newAppError("bad_request", http.StatusBadRequest, "Bad request")
+	ErrValidation       = newAppError("validation_failed", http.StatusUnprocessableEntity, "Validation failed")
+	ErrUnauthorized     = newAppError("unauthorized", http.StatusUnauthorized, "Unauthorized")
+	ErrNotFound         = newAppError("not_found", http.StatusNotFound, "Resource not found")
+	ErrMethodNotAllowed = newAppError("method_not_allowed", http.StatusMethodNotAllowed, "Method not allowed")
+	ErrConflict         = newAppError("conflict", http.StatusConflict, "Conflict")
+	ErrInternal         = newAppError("internal_error", http.StatusInternalServerError, "Internal server error")
+)
+
+func AsAppError(err error) *AppError {
+	var appErr *AppError
+	if errors.As(err, &appErr) {
+		return appErr
+	}
+	return ErrInternal.Wrap(err)
+}
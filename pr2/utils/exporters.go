@@ -0,0 +1,262 @@
+package utils
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+)
+
+type SpanExporter interface {
+	ExportSpan(s *Span)
+	Shutdown(ctx context.Context) error
+}
+
+var (
+	exporterMu sync.RWMutex
+	exporter   SpanExporter = NoopExporter{}
+)
+
+func SetSpanExporter(e SpanExporter) {
+	exporterMu.Lock()
+	defer exporterMu.Unlock()
+	exporter = e
+}
+
+func currentExporter() SpanExporter {
+	exporterMu.RLock()
+	defer exporterMu.RUnlock()
+	return exporter
+}
+
+type NoopExporter struct{}
+
+func (NoopExporter) ExportSpan(*Span)               {}
+func (NoopExporter) Shutdown(context.Context) error { return nil }
+
+type spanJSON struct {
+	TraceID      string         `json:"trace_id"`
+	SpanID       string         `json:"span_id"`
+	ParentSpanID string         `json:"parent_span_id,omitempty"`
+	TraceState   string         `json:"tracestate,omitempty"`
+	Name         string         `json:"name"`
+	Kind         SpanKind       `json:"kind"`
+	Start        time.Time      `json:"start"`
+	End          time.Time      `json:"end"`
+	DurationMs   float64        `json:"duration_ms"`
+	Status       SpanStatus     `json:"status"`
+	StatusMsg    string         `json:"status_message,omitempty"`
+	Attributes   map[string]any `json:"attributes,omitempty"`
+}
+
+type StdoutExporter struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+func NewStdoutExporter() *StdoutExporter {
+	return &StdoutExporter{w: os.Stdout}
+}
+
+func (e *StdoutExporter) ExportSpan(s *Span) {
+	rec := spanJSON{
+		TraceID:    s.Context.TraceID.String(),
+		SpanID:     s.Context.SpanID.String(),
+		TraceState: s.Context.TraceState,
+		Name:       s.Name,
+		Kind:       s.Kind,
+		Start:      s.Start.UTC(),
+		End:        s.End.UTC(),
+		DurationMs: float64(s.End.Sub(s.Start).Microseconds()) / 1000,
+		Status:     s.Status,
+		StatusMsg:  s.StatusMsg,
+		Attributes: s.Attributes(),
+	}
+	if s.ParentSpanID.IsValid() {
+		rec.ParentSpanID = s.ParentSpanID.String()
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	_ = json.NewEncoder(e.w).Encode(map[string]any{"span": rec})
+}
+
+func (e *StdoutExporter) Shutdown(context.Context) error { return nil }
+
+// OTLPExporter копит спаны в пачки и отправляет их в коллектор по OTLP/HTTP в JSON-кодировке.
+type OTLPExporter struct {
+	endpoint    string
+	serviceName string
+	client      *http.Client
+
+	spans chan *Span
+	done  chan struct{}
+	wg    sync.WaitGroup
+}
+
+const (
+	otlpBatchSize     = 512
+	otlpQueueSize     = 2048
+	otlpFlushInterval = 5 * time.Second
+)
+
+func NewOTLPExporter(endpoint, serviceName string) *OTLPExporter {
+	e := &OTLPExporter{
+		endpoint:    endpoint,
+		serviceName: serviceName,
+		client:      &http.Client{Timeout: 10 * time.Second},
+		spans:       make(chan *Span, otlpQueueSize),
+		done:        make(chan struct{}),
+	}
+	e.wg.Add(1)
+	go e.loop()
+	return e
+}
+
+func (e *OTLPExporter) ExportSpan(s *Span) {
+	select {
+	case e.spans <- s:
+	default:
+		Warn(context.Background(), "otlp exporter queue is full, dropping span", "span", s.Name)
+	}
+}
+
+func (e *OTLPExporter) Shutdown(ctx context.Context) error {
+	close(e.done)
+	finished := make(chan struct{})
+	go func() {
+		e.wg.Wait()
+		close(finished)
+	}()
+	select {
+	case <-finished:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (e *OTLPExporter) loop() {
+	defer e.wg.Done()
+	ticker := time.NewTicker(otlpFlushInterval)
+	defer ticker.Stop()
+
+	batch := make([]*Span, 0, otlpBatchSize)
+	flush := func() {
+		if len(batch) == 0 {
+			return
+		}
+		if err := e.send(batch); err != nil {
+			Warn(context.Background(), "otlp export failed", "error", err.Error(), "spans", len(batch))
+		}
+		batch = batch[:0]
+	}
+
+	for {
+		select {
+		case s := <-e.spans:
+			batch = append(batch, s)
+			if len(batch) >= otlpBatchSize {
+				flush()
+			}
+		case <-ticker.C:
+			flush()
+		case <-e.done:
+			for {
+				select {
+				case s := <-e.spans:
+					batch = append(batch, s)
+				default:
+					flush()
+					return
+				}
+			}
+		}
+	}
+}
+
+func (e *OTLPExporter) send(batch []*Span) error {
+	spans := make([]map[string]any, 0, len(batch))
+	for _, s := range batch {
+		span := map[string]any{
+			"traceId":           s.Context.TraceID.String(),
+			"spanId":            s.Context.SpanID.String(),
+			"name":              s.Name,
+			"kind":              int(s.Kind),
+			"startTimeUnixNano": strconv.FormatInt(s.Start.UnixNano(), 10),
+			"endTimeUnixNano":   strconv.FormatInt(s.End.UnixNano(), 10),
+			"attributes":        otlpAttributes(s.Attributes()),
+			"status":            map[string]any{"code": int(s.Status), "message": s.StatusMsg},
+		}
+		if s.ParentSpanID.IsValid() {
+			span["parentSpanId"] = s.ParentSpanID.String()
+		}
+		if s.Context.TraceState != "" {
+			span["traceState"] = s.Context.TraceState
+		}
+		spans = append(spans, span)
+	}
+
+	payload := map[string]any{
+		"resourceSpans": []any{map[string]any{
+			"resource": map[string]any{
+				"attributes": otlpAttributes(map[string]any{"service.name": e.serviceName}),
+			},
+			"scopeSpans": []any{map[string]any{
+				"scope": map[string]any{"name": "github.com/icestormerrr/myapp/utils"},
+				"spans": spans,
+			}},
+		}},
+	}
+
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+	resp, err := e.client.Post(e.endpoint, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, resp.Body)
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("collector responded with %s", resp.Status)
+	}
+	return nil
+}
+
+func otlpAttributes(attrs map[string]any) []map[string]any {
+	keys := make([]string, 0, len(attrs))
+	for k := range attrs {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	out := make([]map[string]any, 0, len(attrs))
+	for _, k := range keys {
+		var value map[string]any
+		switch v := attrs[k].(type) {
+		case string:
+			value = map[string]any{"stringValue": v}
+		case bool:
+			value = map[string]any{"boolValue": v}
+		case int:
+			value = map[string]any{"intValue": strconv.Itoa(v)}
+		case int64:
+			value = map[string]any{"intValue": strconv.FormatInt(v, 10)}
+		case float64:
+			value = map[string]any{"doubleValue": v}
+		default:
+			value = map[string]any{"stringValue": fmt.Sprint(v)}
+		}
+		out = append(out, map[string]any{"key": k, "value": value})
+	}
+	return out
+}
@@ -0,0 +1,51 @@
+package utils
+
+import (
+	"net/http"
+	"time"
+)
+
+// TracingTransport передаёт traceparent, tracestate и X-Request-Id в исходящие запросы
+// и оборачивает каждый вызов в клиентский спан.
+type TracingTransport struct {
+	Base http.RoundTripper
+}
+
+func (t *TracingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	base := t.Base
+	if base == nil {
+		base = http.DefaultTransport
+	}
+
+	ctx, span := StartSpan(req.Context(), req.Method+" "+req.URL.Host, SpanKindClient)
+	defer span.Finish()
+	span.SetAttribute("http.method", req.Method)
+	span.SetAttribute("url.full", req.URL.Redacted())
+
+	out := req.Clone(ctx)
+	out.Header.Set("traceparent", span.Context.Traceparent())
+	if span.Context.TraceState != "" {
+		out.Header.Set("tracestate", span.Context.TraceState)
+	}
+	if id := RequestID(ctx); id != "" {
+		out.Header.Set("X-Request-Id", id)
+	}
+
+	resp, err := base.RoundTrip(out)
+	if err != nil {
+		span.SetStatus(SpanStatusError, err.Error())
+		return nil, err
+	}
+	span.SetAttribute("http.status_code", resp.StatusCode)
+	if resp.StatusCode >= http.StatusBadRequest {
+		span.SetStatus(SpanStatusError, resp.Status)
+	}
+	return resp, nil
+}
+
+func NewHTTPClient(timeout time.Duration) *http.Client {
+	return &http.Client{
+		Timeout:   timeout,
+		Transport: &TracingTransport{Base: http.DefaultTransport},
+	}
+}
@@ -5,16 +5,53 @@ import (
 	"net/http"
 )
 
-type JSONError struct {
-	Error string `json:"error"`
+type Problem struct {
+	Type       string      `json:"type"`
+	Title      string      `json:"title"`
+	Status     int         `json:"status"`
+	Detail     string      `json:"detail,omitempty"`
+	Instance   string      `json:"instance,omitempty"`
+	Code       string      `json:"code"`
+	RequestID  string      `json:"request_id,omitempty"`
+	Violations []Violation `json:"violations,omitempty"`
 }
 
+const problemTypePrefix = "urn:myapp:error:"
+
 func WriteJSON(w http.ResponseWriter, code int, v any) {
 	w.Header().Set("Content-Type", "application/json; charset=utf-8")
 	w.WriteHeader(code)
 	_ = json.NewEncoder(w).Encode(v)
 }
 
-func WriteErr(w http.ResponseWriter, code int, msg string) {
-	WriteJSON(w, code, JSONError{Error: msg})
+func NewProblem(r *http.Request, err error) Problem {
+	appErr := AsAppError(err)
+	return Problem{
+		Type:       problemTypePrefix + appErr.Code,
+		Title:      appErr.Title,
+		Status:     appErr.Status,
+		Detail:     appErr.Detail,
+		Instance:   r.URL.Path,
+		Code:       appErr.Code,
+		RequestID:  RequestID(r.Context()),
+		Violations: appErr.Violations,
+	}
+}
+
+func WriteProblem(w http.ResponseWriter, p any, status int) {
+	w.Header().Set("Content-Type", "application/problem+json; charset=utf-8")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(p)
+}
+
+// WriteErr отвечает ошибкой в формате RFC 7807 (application/problem+json).
+// Ошибки не из каталога превращаются в internal_error, их текст уходит только в лог.
+func WriteErr(w http.ResponseWriter, r *http.Request, err error) {
+	appErr := AsAppError(err)
+	if appErr.Status >= http.StatusInternalServerError {
+		Error(r.Context(), "request failed", "code", appErr.Code, "error", appErr.Error())
+	} else {
+		Debug(r.Context(), "request rejected", "code", appErr.Code, "error", appErr.Error())
+	}
+	WriteProblem(w, NewProblem(r, appErr), appErr.Status)
 }
@@ -1,11 +1,25 @@
 package utils
 
 import (
+	"context"
 	"crypto/rand"
 	"encoding/hex"
 	"fmt"
+	"log/slog"
 	"net/http"
-	"time"
+	"os"
+	"strings"
+)
+
+type ctxKey int
+
+const requestIDKey ctxKey = iota
+
+var (
+	logLevel = new(slog.LevelVar)
+	logger   = slog.New(&contextHandler{
+		Handler: slog.NewJSONHandler(os.Stdout, &slog.HandlerOptions{Level: logLevel}),
+	})
 )
 
 func NewID16() string {
@@ -14,20 +28,91 @@ func NewID16() string {
 	return hex.EncodeToString(b)
 }
 
-func LogRequest(r *http.Request) {
-	fmt.Printf("[%s] %s %s %s\n",
-		time.Now().Format(time.RFC3339),
-		r.RemoteAddr,
-		r.Method,
-		r.URL.Path,
-	)
+func WithRequestID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, requestIDKey, id)
+}
+
+func RequestID(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDKey).(string)
+	return id
 }
 
-func LogInfo(msg string) {
+// contextHandler добавляет request_id и идентификаторы трейса из контекста в каждую запись лога.
+type contextHandler struct {
+	slog.Handler
+}
+
+func (h *contextHandler) Handle(ctx context.Context, rec slog.Record) error {
+	if id := RequestID(ctx); id != "" {
+		rec.AddAttrs(slog.String("request_id", id))
+	}
+	if span := SpanFromContext(ctx); span != nil {
+		rec.AddAttrs(
+			slog.String("trace_id", span.Context.TraceID.String()),
+			slog.String("span_id", span.Context.SpanID.String()),
+		)
+	}
+	return h.Handler.Handle(ctx, rec)
+}
+
+func (h *contextHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &contextHandler{Handler: h.Handler.WithAttrs(attrs)}
+}
+
+func (h *contextHandler) WithGroup(name string) slog.Handler {
+	return &contextHandler{Handler: h.Handler.WithGroup(name)}
+}
 
-	fmt.Printf("[INFO] %s %s\n", time.Now().Format(time.RFC3339), msg)
+func ParseLevel(s string) (slog.Level, error) {
+	var level slog.Level
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "debug":
+		level = slog.LevelDebug
+	case "info":
+		level = slog.LevelInfo
+	case "warn", "warning":
+		level = slog.LevelWarn
+	case "error":
+		level = slog.LevelError
+	default:
+		return level, fmt.Errorf("unknown log level %q", s)
+	}
+	return level, nil
 }
 
-func LogError(msg string) {
-	fmt.Printf("[ERROR] %s %s\n", time.Now().Format(time.RFC3339), msg)
+func SetLevel(s string) error {
+	level, err := ParseLevel(s)
+	if err != nil {
+		return err
+	}
+	logLevel.Set(level)
+	return nil
+}
+
+func Level() string {
+	return strings.ToLower(logLevel.Level().String())
+}
+
+func Debug(ctx context.Context, msg string, args ...any) {
+	logger.DebugContext(ctx, msg, args...)
+}
+
+func Info(ctx context.Context, msg string, args ...any) {
+	logger.InfoContext(ctx, msg, args...)
+}
+
+func Warn(ctx context.Context, msg string, args ...any) {
+	logger.WarnContext(ctx, msg, args...)
+}
+
+func Error(ctx context.Context, msg string, args ...any) {
+	logger.ErrorContext(ctx, msg, args...)
+}
+
+func LogRequest(r *http.Request) {
+	Info(r.Context(), "request",
+		"remote_addr", r.RemoteAddr,
+		"method", r.Method,
+		"path", r.URL.Path,
+	)
 }
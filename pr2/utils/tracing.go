@@ -0,0 +1,215 @@
+package utils
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"strings"
+	"sync"
+	"time"
+)
+
+type SpanKind int
+
+const (
+	SpanKindInternal SpanKind = 1
+	SpanKindServer   SpanKind = 2
+	SpanKindClient   SpanKind = 3
+)
+
+type SpanStatus int
+
+const (
+	SpanStatusUnset SpanStatus = 0
+	SpanStatusOK    SpanStatus = 1
+	SpanStatusError SpanStatus = 2
+)
+
+const flagSampled = 0x01
+
+type TraceID [16]byte
+type SpanID [8]byte
+
+func (id TraceID) String() string { return hex.EncodeToString(id[:]) }
+func (id SpanID) String() string  { return hex.EncodeToString(id[:]) }
+func (id TraceID) IsValid() bool  { return id != TraceID{} }
+func (id SpanID) IsValid() bool   { return id != SpanID{} }
+
+type SpanContext struct {
+	TraceID    TraceID
+	SpanID     SpanID
+	Flags      byte
+	TraceState string
+}
+
+func (sc SpanContext) IsValid() bool { return sc.TraceID.IsValid() && sc.SpanID.IsValid() }
+func (sc SpanContext) Sampled() bool { return sc.Flags&flagSampled != 0 }
+
+// Traceparent формирует заголовок версии 00: 00-<trace-id>-<parent-id>-<flags>.
+func (sc SpanContext) Traceparent() string {
+	return "00-" + sc.TraceID.String() + "-" + sc.SpanID.String() + "-" + hex.EncodeToString([]byte{sc.Flags})
+}
+
+var errInvalidTraceparent = errors.New("invalid traceparent")
+
+func ParseTraceparent(header string) (SpanContext, error) {
+	var sc SpanContext
+	header = strings.TrimSpace(header)
+	if len(header) < 55 {
+		return sc, errInvalidTraceparent
+	}
+	parts := strings.Split(header[:55], "-")
+	if len(parts) != 4 || len(parts[0]) != 2 || len(parts[1]) != 32 || len(parts[2]) != 16 || len(parts[3]) != 2 {
+		return sc, errInvalidTraceparent
+	}
+	for _, p := range parts {
+		if p != strings.ToLower(p) {
+			return sc, errInvalidTraceparent
+		}
+	}
+
+	version, err := hex.DecodeString(parts[0])
+	if err != nil || version[0] == 0xff {
+		return sc, errInvalidTraceparent
+	}
+	// Версия 00 не допускает ничего после флагов; более новые версии могут дописывать поля через "-".
+	if version[0] == 0 && len(header) != 55 {
+		return sc, errInvalidTraceparent
+	}
+	if version[0] != 0 && len(header) > 55 && header[55] != '-' {
+		return sc, errInvalidTraceparent
+	}
+
+	if _, err := hex.Decode(sc.TraceID[:], []byte(parts[1])); err != nil {
+		return sc, errInvalidTraceparent
+	}
+	if _, err := hex.Decode(sc.SpanID[:], []byte(parts[2])); err != nil {
+		return sc, errInvalidTraceparent
+	}
+	flags, err := hex.DecodeString(parts[3])
+	if err != nil {
+		return sc, errInvalidTraceparent
+	}
+	sc.Flags = flags[0]
+
+	if !sc.IsValid() {
+		return sc, errInvalidTraceparent
+	}
+	return sc, nil
+}
+
+// ParseTracestate отбрасывает пустые элементы и всё сверх 32 элементов списка.
+func ParseTracestate(header string) string {
+	var members []string
+	for _, m := range strings.Split(header, ",") {
+		m = strings.TrimSpace(m)
+		if m == "" || !strings.Contains(m, "=") {
+			continue
+		}
+		members = append(members, m)
+		if len(members) == 32 {
+			break
+		}
+	}
+	return strings.Join(members, ",")
+}
+
+type Span struct {
+	Name         string
+	Kind         SpanKind
+	Context      SpanContext
+	ParentSpanID SpanID
+	Start        time.Time
+	End          time.Time
+	Status       SpanStatus
+	StatusMsg    string
+
+	mu         sync.Mutex
+	attributes map[string]any
+	ended      bool
+}
+
+func (s *Span) SetName(name string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.Name = name
+}
+
+func (s *Span) SetAttribute(key string, value any) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.attributes[key] = value
+}
+
+func (s *Span) SetStatus(status SpanStatus, msg string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.Status = status
+	s.StatusMsg = msg
+}
+
+func (s *Span) Attributes() map[string]any {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	out := make(map[string]any, len(s.attributes))
+	for k, v := range s.attributes {
+		out[k] = v
+	}
+	return out
+}
+
+func (s *Span) Finish() {
+	s.mu.Lock()
+	if s.ended {
+		s.mu.Unlock()
+		return
+	}
+	s.ended = true
+	s.End = time.Now()
+	s.mu.Unlock()
+
+	if s.Context.Sampled() {
+		currentExporter().ExportSpan(s)
+	}
+}
+
+type spanKey struct{}
+type remoteSpanKey struct{}
+
+func SpanFromContext(ctx context.Context) *Span {
+	s, _ := ctx.Value(spanKey{}).(*Span)
+	return s
+}
+
+func ContextWithRemoteSpanContext(ctx context.Context, sc SpanContext) context.Context {
+	return context.WithValue(ctx, remoteSpanKey{}, sc)
+}
+
+// StartSpan создаёт дочерний спан текущего спана из контекста, иначе — удалённого родителя
+// из traceparent, иначе начинает новый трейс.
+func StartSpan(ctx context.Context, name string, kind SpanKind) (context.Context, *Span) {
+	s := &Span{
+		Name:       name,
+		Kind:       kind,
+		Start:      time.Now(),
+		attributes: make(map[string]any),
+	}
+
+	switch parent := SpanFromContext(ctx); {
+	case parent != nil:
+		s.Context = parent.Context
+		s.ParentSpanID = parent.Context.SpanID
+	default:
+		if remote, ok := ctx.Value(remoteSpanKey{}).(SpanContext); ok && remote.IsValid() {
+			s.Context = remote
+			s.ParentSpanID = remote.SpanID
+		} else {
+			_, _ = rand.Read(s.Context.TraceID[:])
+			s.Context.Flags = flagSampled
+		}
+	}
+	_, _ = rand.Read(s.Context.SpanID[:])
+
+	return context.WithValue(ctx, spanKey{}, s), s
+}
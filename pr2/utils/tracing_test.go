@@ -0,0 +1,82 @@
+package utils
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseTraceparent(t *testing.T) {
+	const (
+		traceID = "4bf92f3577b34da6a3ce929d0e0e4736"
+		spanID  = "00f067aa0ba902b7"
+	)
+	for _, tc := range []struct {
+		name    string
+		header  string
+		ok      bool
+		sampled bool
+	}{
+		{name: "sampled", header: "00-" + traceID + "-" + spanID + "-01", ok: true, sampled: true},
+		{name: "not sampled", header: "00-" + traceID + "-" + spanID + "-00", ok: true},
+		{name: "surrounding spaces", header: "  00-" + traceID + "-" + spanID + "-01 ", ok: true, sampled: true},
+		// будущие версии могут дописывать поля после флагов
+		{name: "future version with extra field", header: "cc-" + traceID + "-" + spanID + "-01-what", ok: true, sampled: true},
+		{name: "future version without extra field", header: "cc-" + traceID + "-" + spanID + "-01", ok: true, sampled: true},
+		{name: "future version with garbage suffix", header: "cc-" + traceID + "-" + spanID + "-01x", ok: false},
+		{name: "version 00 with extra field", header: "00-" + traceID + "-" + spanID + "-01-what", ok: false},
+		{name: "forbidden version ff", header: "ff-" + traceID + "-" + spanID + "-01", ok: false},
+		{name: "non-hex version", header: "0g-" + traceID + "-" + spanID + "-01", ok: false},
+		{name: "uppercase", header: "00-" + strings.ToUpper(traceID) + "-" + spanID + "-01", ok: false},
+		{name: "all-zero trace id", header: "00-" + strings.Repeat("0", 32) + "-" + spanID + "-01", ok: false},
+		{name: "all-zero span id", header: "00-" + traceID + "-" + strings.Repeat("0", 16) + "-01", ok: false},
+		{name: "non-hex trace id", header: "00-" + strings.Repeat("z", 32) + "-" + spanID + "-01", ok: false},
+		{name: "short span id", header: "00-" + traceID + "-" + spanID[:15] + "-001", ok: false},
+		{name: "wrong separator", header: "00_" + traceID + "-" + spanID + "-01", ok: false},
+		{name: "too short", header: "00-" + traceID + "-" + spanID, ok: false},
+		{name: "empty", header: "", ok: false},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			sc, err := ParseTraceparent(tc.header)
+			if (err == nil) != tc.ok {
+				t.Fatalf("ParseTraceparent(%q): err = %v, want ok = %v", tc.header, err, tc.ok)
+			}
+			if !tc.ok {
+				return
+			}
+			if sc.TraceID.String() != traceID || sc.SpanID.String() != spanID || sc.Sampled() != tc.sampled {
+				t.Errorf("unexpected span context %+v", sc)
+			}
+		})
+	}
+}
+
+func TestSpanContext_TraceparentRoundTrip(t *testing.T) {
+	header := "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"
+	sc, err := ParseTraceparent(header)
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	if got := sc.Traceparent(); got != header {
+		t.Errorf("Traceparent() = %q, want %q", got, header)
+	}
+}
+
+func TestParseTracestate(t *testing.T) {
+	var many []string
+	for i := 0; i < 40; i++ {
+		many = append(many, "k"+strings.Repeat("x", i)+"=v")
+	}
+	for _, tc := range []struct {
+		header string
+		want   string
+	}{
+		{"", ""},
+		{"congo=t61rcWkgMzE, rojo=00f067aa0ba902b7", "congo=t61rcWkgMzE,rojo=00f067aa0ba902b7"},
+		{" ,bogus, a=1,,", "a=1"},
+		{strings.Join(many, ","), strings.Join(many[:32], ",")},
+	} {
+		if got := ParseTracestate(tc.header); got != tc.want {
+			t.Errorf("ParseTracestate(%q) = %q, want %q", tc.header, got, tc.want)
+		}
+	}
+}
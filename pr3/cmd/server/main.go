@@ -1,17 +1,29 @@
 package main
 
 import (
+	"context"
+	"errors"
 	"log"
 	"net/http"
 	"os"
+	"os/signal"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
 
 	"github.com/icestormerrr/pz3-http/internal/api"
+	"github.com/icestormerrr/pz3-http/internal/idempotency"
 	"github.com/icestormerrr/pz3-http/internal/storage"
 )
 
 func main() {
-	store := storage.NewMemoryStore()
+	store, err := openStore()
+	if err != nil {
+		log.Fatal(err)
+	}
 	h := api.NewHandlers(store)
+	h.RequireIfMatch = getBool("REQUIRE_IF_MATCH", false)
 
 	mux := http.NewServeMux()
 	mux.HandleFunc("GET /health", func(w http.ResponseWriter, r *http.Request) {
@@ -19,17 +31,60 @@ func main() {
 	})
 
 	mux.HandleFunc("GET /tasks", h.ListTasks)
-	mux.HandleFunc("POST /tasks", h.CreateTask)
+	idem := idempotency.NewMemoryStore(getDuration("IDEMPOTENCY_TTL", 24*time.Hour), getInt("IDEMPOTENCY_MAX_KEYS", 10000))
+	mux.Handle("POST /tasks", api.WithIdempotency(idem, http.HandlerFunc(h.CreateTask)))
+	mux.Handle("POST /tasks:batch", api.WithIdempotency(idem, http.HandlerFunc(h.BatchTasks)))
 	mux.HandleFunc("PATCH /tasks/", h.UpdateTask)
 	mux.HandleFunc("DELETE /tasks/", h.DeleteTask)
+	gqlOpts := api.DefaultGraphQLOptions()
+	gqlOpts.Introspection = getBool("GRAPHQL_INTROSPECTION", gqlOpts.Introspection)
+	gqlOpts.MaxDepth = getInt("GRAPHQL_MAX_DEPTH", gqlOpts.MaxDepth)
+	gqlOpts.MaxComplexity = getInt("GRAPHQL_MAX_COMPLEXITY", gqlOpts.MaxComplexity)
+	gql, err := api.NewGraphQLHandler(h, gqlOpts)
+	if err != nil {
+		log.Fatal(err)
+	}
+	mux.Handle("POST /graphql", gql)
+
+	mux.HandleFunc("GET /tasks/events", h.TaskEvents)
+	mux.HandleFunc("GET /tasks/export", h.ExportTasks)
+	mux.HandleFunc("POST /tasks/import", h.ImportTasks)
+	mux.HandleFunc("POST /tasks/{id}/dependencies", h.AddDependency)
+	mux.HandleFunc("DELETE /tasks/{id}/dependencies/{blocker}", h.RemoveDependency)
+	mux.HandleFunc("GET /tasks/{id}/graph", h.TaskGraph)
 	mux.HandleFunc("GET /tasks/", h.GetTask)
 
-	handler := api.WithCORS(api.WithLogging(mux))
+	cors, err := corsPolicy()
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	handler := api.WithCORS(cors, api.WithLogging(mux))
 	addr := getAddr()
+	srv := &http.Server{Addr: addr, Handler: handler}
+	srv.RegisterOnShutdown(store.Events().Close)
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	// ListenAndServe возвращается сразу после начала Shutdown, поэтому ждём,
+	// пока завершатся обработчики, и только потом закрываем хранилище.
+	drained := make(chan struct{})
+	go func() {
+		defer close(drained)
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		_ = srv.Shutdown(shutdownCtx)
+	}()
+
 	log.Println("listening on", addr)
-	if err := http.ListenAndServe(addr, handler); err != nil {
+	if err := srv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
 		log.Fatal(err)
 	}
+	<-drained
+	if err := store.Close(); err != nil {
+		log.Println("failed to close store:", err)
+	}
 }
 
 func getAddr() string {
@@ -39,3 +94,64 @@ func getAddr() string {
 	}
 	return ":" + port
 }
+
+// Без DATA_DIR задачи живут только в памяти, как раньше.
+func openStore() (*storage.MemoryStore, error) {
+	dir := os.Getenv("DATA_DIR")
+	if dir == "" {
+		return storage.NewMemoryStore(), nil
+	}
+	return storage.OpenMemoryStore(storage.DurableOptions{
+		Dir:              dir,
+		SyncEvery:        getInt("WAL_SYNC_EVERY", 1),
+		SyncInterval:     getDuration("WAL_SYNC_INTERVAL", 100*time.Millisecond),
+		SnapshotEvery:    getInt("SNAPSHOT_EVERY", 1000),
+		SnapshotInterval: getDuration("SNAPSHOT_INTERVAL", 5*time.Minute),
+	})
+}
+
+func corsPolicy() (api.CORSPolicy, error) {
+	p := api.DefaultCORSPolicy()
+	p.AllowedOrigins = getList("CORS_ALLOWED_ORIGINS", p.AllowedOrigins)
+	p.AllowedMethods = getList("CORS_ALLOWED_METHODS", p.AllowedMethods)
+	p.AllowedHeaders = getList("CORS_ALLOWED_HEADERS", p.AllowedHeaders)
+	p.ExposedHeaders = getList("CORS_EXPOSED_HEADERS", p.ExposedHeaders)
+	p.AllowCredentials = getBool("CORS_ALLOW_CREDENTIALS", p.AllowCredentials)
+	p.MaxAge = getDuration("CORS_MAX_AGE", p.MaxAge)
+	return p, p.Validate()
+}
+
+func getList(key string, def []string) []string {
+	v := os.Getenv(key)
+	if v == "" {
+		return def
+	}
+	var out []string
+	for _, item := range strings.Split(v, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			out = append(out, item)
+		}
+	}
+	return out
+}
+
+func getBool(key string, def bool) bool {
+	if v, err := strconv.ParseBool(os.Getenv(key)); err == nil {
+		return v
+	}
+	return def
+}
+
+func getInt(key string, def int) int {
+	if v, err := strconv.Atoi(os.Getenv(key)); err == nil {
+		return v
+	}
+	return def
+}
+
+func getDuration(key string, def time.Duration) time.Duration {
+	if v, err := time.ParseDuration(os.Getenv(key)); err == nil {
+		return v
+	}
+	return def
+}
@@ -1,3 +1,5 @@
 module github.com/icestormerrr/pz3-http
 
 go 1.22.4
+
+require github.com/graphql-go/graphql v0.8.1
@@ -0,0 +1,219 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/icestormerrr/pz3-http/internal/storage"
+)
+
+const maxBatchSize = 1000
+
+type batchRequest struct {
+	Operations []batchOperation `json:"operations"`
+}
+
+type batchOperation struct {
+	Op      string            `json:"op"`
+	ID      int64             `json:"id"`
+	IfMatch string            `json:"if_match"`
+	Task    createTaskRequest `json:"task"`
+	Patch   json.RawMessage   `json:"patch"`
+}
+
+type batchItemResult struct {
+	Index  int           `json:"index"`
+	Status int           `json:"status"`
+	Task   *storage.Task `json:"task,omitempty"`
+	Error  string        `json:"error,omitempty"`
+}
+
+type batchResponse struct {
+	Atomic  bool              `json:"atomic"`
+	Applied bool              `json:"applied"`
+	Results []batchItemResult `json:"results"`
+}
+
+// POST /tasks:batch — по умолчанию пакет применяется атомарно (всё или ничего),
+// с ?atomic=false каждая операция выполняется сама по себе.
+func (h *Handlers) BatchTasks(w http.ResponseWriter, r *http.Request) {
+	if r.Header.Get("Content-Type") != "" && !strings.Contains(r.Header.Get("Content-Type"), "application/json") {
+		BadRequest(w, "Content-Type must be application/json")
+		return
+	}
+
+	atomic := true
+	if v := r.URL.Query().Get("atomic"); v != "" {
+		var err error
+		if atomic, err = strconv.ParseBool(v); err != nil {
+			BadRequest(w, "atomic must be true or false")
+			return
+		}
+	}
+
+	body, ok := readBody(w, r, maxBodySize)
+	if !ok {
+		return
+	}
+	var req batchRequest
+	dec := json.NewDecoder(bytes.NewReader(body))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&req); err != nil {
+		BadRequest(w, "invalid json: "+err.Error())
+		return
+	}
+	if len(req.Operations) == 0 {
+		BadRequest(w, "operations are required")
+		return
+	}
+	if len(req.Operations) > maxBatchSize {
+		Unprocessable(w, "too many operations (max "+strconv.Itoa(maxBatchSize)+")")
+		return
+	}
+
+	results := make([]batchItemResult, len(req.Operations))
+	ops := make([]storage.BatchOp, 0, len(req.Operations))
+	// index[i] — позиция операции ops[i] в исходном запросе
+	index := make([]int, 0, len(req.Operations))
+	invalidOps := false
+	for i, op := range req.Operations {
+		results[i].Index = i
+		bop, status, err := h.toBatchOp(op)
+		if err != nil {
+			results[i].Status, results[i].Error = status, err.Error()
+			invalidOps = true
+			continue
+		}
+		ops = append(ops, bop)
+		index = append(index, i)
+	}
+
+	resp := batchResponse{Atomic: atomic, Results: results}
+
+	// В атомарном режиме ошибка проверки хотя бы одной операции отменяет весь пакет.
+	if atomic && invalidOps {
+		status := abortResults(results)
+		JSON(w, status, resp)
+		return
+	}
+
+	applied, err := h.Store.Batch(ops, atomic)
+	if err != nil && !errors.Is(err, storage.ErrBatchAborted) {
+		Internal(w, "failed to apply batch")
+		return
+	}
+	for j, res := range applied {
+		i := index[j]
+		results[i].Status, results[i].Task = batchStatus(ops[j].Kind, res.Err), res.Task
+		if res.Err != nil {
+			results[i].Error = batchErrorMessage(res.Err)
+		}
+	}
+
+	if errors.Is(err, storage.ErrBatchAborted) {
+		status := abortResults(results)
+		JSON(w, status, resp)
+		return
+	}
+
+	resp.Applied = true
+	JSON(w, http.StatusOK, resp)
+}
+
+func (h *Handlers) toBatchOp(op batchOperation) (storage.BatchOp, int, error) {
+	bop := storage.BatchOp{Kind: storage.BatchOpKind(op.Op), ID: op.ID}
+
+	switch bop.Kind {
+	case storage.BatchCreate:
+		in, err := op.Task.toNewTask()
+		if err != nil {
+			return bop, validationStatus(err), err
+		}
+		bop.Create = in
+		return bop, 0, nil
+
+	case storage.BatchUpdate, storage.BatchDelete:
+		if op.ID <= 0 {
+			return bop, http.StatusBadRequest, errors.New("id is required")
+		}
+		if op.IfMatch != "" {
+			bop.Match = parseETagList([]string{op.IfMatch})
+		}
+		if h.RequireIfMatch && bop.Match == nil {
+			return bop, http.StatusPreconditionRequired, errors.New("if_match is required")
+		}
+		if bop.Kind == storage.BatchUpdate {
+			payload, err := parseMergePatch(op.Patch)
+			if err != nil {
+				return bop, validationStatus(err), err
+			}
+			bop.Update = payload
+		}
+		return bop, 0, nil
+	}
+	return bop, http.StatusBadRequest, errors.New("op must be one of create, update, delete")
+}
+
+// abortResults помечает неприменённые операции кодом 424 и возвращает
+// статус ответа — код первой ошибки.
+func abortResults(results []batchItemResult) int {
+	status := 0
+	for i := range results {
+		r := &results[i]
+		r.Task = nil
+		if r.Error != "" && r.Status != http.StatusFailedDependency {
+			if status == 0 {
+				status = r.Status
+			}
+			continue
+		}
+		r.Status, r.Error = http.StatusFailedDependency, "not applied: batch aborted"
+	}
+	if status == 0 {
+		status = http.StatusConflict
+	}
+	return status
+}
+
+func validationStatus(err error) int {
+	if ve, ok := err.(*validationError); ok {
+		return ve.Status
+	}
+	return http.StatusBadRequest
+}
+
+func batchStatus(kind storage.BatchOpKind, err error) int {
+	switch {
+	case errors.As(err, new(*storage.BlockedError)), errors.As(err, new(*storage.DoneDependentsError)):
+		return http.StatusConflict
+	case errors.Is(err, storage.ErrBatchAborted):
+		return http.StatusFailedDependency
+	case errors.Is(err, storage.ErrNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, storage.ErrVersionMismatch):
+		return http.StatusPreconditionFailed
+	case err != nil:
+		return http.StatusInternalServerError
+	case kind == storage.BatchCreate:
+		return http.StatusCreated
+	case kind == storage.BatchDelete:
+		return http.StatusNoContent
+	}
+	return http.StatusOK
+}
+
+func batchErrorMessage(err error) string {
+	switch {
+	case errors.Is(err, storage.ErrBatchAborted):
+		return "not applied: batch aborted"
+	case errors.Is(err, storage.ErrNotFound):
+		return "task not found"
+	case errors.Is(err, storage.ErrVersionMismatch):
+		return "task was modified, reload it and retry"
+	}
+	return err.Error()
+}
@@ -0,0 +1,167 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// CORSPolicy задаёт, каким источникам и с какими методами/заголовками разрешено
+// обращаться к API из браузера. Источник можно указать точно (https://app.example.com),
+// шаблоном поддомена (https://*.example.com) или "*" для всех.
+type CORSPolicy struct {
+	AllowedOrigins   []string
+	AllowedMethods   []string
+	AllowedHeaders   []string
+	ExposedHeaders   []string
+	AllowCredentials bool
+	MaxAge           time.Duration
+}
+
+func DefaultCORSPolicy() CORSPolicy {
+	return CORSPolicy{
+		AllowedOrigins: []string{"*"},
+		AllowedMethods: []string{http.MethodGet, http.MethodPost, http.MethodPatch, http.MethodDelete},
+		AllowedHeaders: []string{"Content-Type", "If-Match", "If-None-Match", "Idempotency-Key", "Last-Event-ID"},
+		ExposedHeaders: []string{"ETag", "Link", "X-Total-Count", "Idempotent-Replayed"},
+		MaxAge:         10 * time.Minute,
+	}
+}
+
+func (p CORSPolicy) Validate() error {
+	for _, o := range p.AllowedOrigins {
+		if o == "*" && p.AllowCredentials {
+			return errors.New("cors: credentials cannot be allowed for any origin, list origins explicitly")
+		}
+		if o != "*" && !strings.Contains(o, "://") {
+			return errors.New("cors: origin " + o + " must include a scheme")
+		}
+	}
+	if p.MaxAge < 0 {
+		return errors.New("cors: max age must not be negative")
+	}
+	return nil
+}
+
+func (p CORSPolicy) allowAnyOrigin() bool {
+	for _, o := range p.AllowedOrigins {
+		if o == "*" {
+			return true
+		}
+	}
+	return false
+}
+
+func (p CORSPolicy) originAllowed(origin string) bool {
+	origin = strings.ToLower(origin)
+	for _, o := range p.AllowedOrigins {
+		o = strings.ToLower(o)
+		if o == "*" || o == origin {
+			return true
+		}
+		// https://*.example.com подходит для https://app.example.com, но не для https://example.com
+		scheme, host, ok := strings.Cut(o, "://*.")
+		if ok && strings.HasPrefix(origin, scheme+"://") && strings.HasSuffix(origin, "."+host) {
+			return true
+		}
+	}
+	return false
+}
+
+func (p CORSPolicy) methodAllowed(method string) bool {
+	for _, m := range p.AllowedMethods {
+		if strings.EqualFold(m, method) {
+			return true
+		}
+	}
+	return false
+}
+
+func (p CORSPolicy) headersAllowed(requested string) bool {
+	for _, h := range strings.Split(requested, ",") {
+		h = strings.TrimSpace(h)
+		if h == "" {
+			continue
+		}
+		allowed := false
+		for _, a := range p.AllowedHeaders {
+			if a == "*" || strings.EqualFold(a, h) {
+				allowed = true
+				break
+			}
+		}
+		if !allowed {
+			return false
+		}
+	}
+	return true
+}
+
+func WithCORS(policy CORSPolicy, next http.Handler) http.Handler {
+	methods := strings.Join(policy.AllowedMethods, ", ")
+	headers := strings.Join(policy.AllowedHeaders, ", ")
+	exposed := strings.Join(policy.ExposedHeaders, ", ")
+	maxAge := strconv.Itoa(int(policy.MaxAge.Seconds()))
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		origin := r.Header.Get("Origin")
+		// Ответ зависит от Origin, иначе кеш может отдать его другому источнику.
+		w.Header().Add("Vary", "Origin")
+
+		// Preflight — это OPTIONS с Origin и Access-Control-Request-Method,
+		// остальные OPTIONS обрабатываются как обычные запросы.
+		preflight := r.Method == http.MethodOptions && origin != "" && r.Header.Get("Access-Control-Request-Method") != ""
+
+		if origin == "" {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		if !policy.originAllowed(origin) {
+			if preflight {
+				w.WriteHeader(http.StatusForbidden)
+				return
+			}
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		if policy.allowAnyOrigin() && !policy.AllowCredentials {
+			w.Header().Set("Access-Control-Allow-Origin", "*")
+		} else {
+			w.Header().Set("Access-Control-Allow-Origin", origin)
+		}
+		if policy.AllowCredentials {
+			w.Header().Set("Access-Control-Allow-Credentials", "true")
+		}
+
+		if !preflight {
+			if exposed != "" {
+				w.Header().Set("Access-Control-Expose-Headers", exposed)
+			}
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		w.Header().Add("Vary", "Access-Control-Request-Method")
+		w.Header().Add("Vary", "Access-Control-Request-Headers")
+		reqHeaders := r.Header.Get("Access-Control-Request-Headers")
+		if !policy.methodAllowed(r.Header.Get("Access-Control-Request-Method")) || !policy.headersAllowed(reqHeaders) {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+
+		w.Header().Set("Access-Control-Allow-Methods", methods)
+		if headers == "*" && reqHeaders != "" {
+			w.Header().Set("Access-Control-Allow-Headers", reqHeaders)
+		} else if headers != "" {
+			w.Header().Set("Access-Control-Allow-Headers", headers)
+		}
+		if policy.MaxAge > 0 {
+			w.Header().Set("Access-Control-Max-Age", maxAge)
+		}
+		w.WriteHeader(http.StatusNoContent)
+	})
+}
@@ -0,0 +1,57 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestWithCORS_Preflight(t *testing.T) {
+	policy := DefaultCORSPolicy()
+	policy.AllowedOrigins = []string{"https://app.example.com", "https://*.example.org"}
+	policy.AllowCredentials = true
+
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusTeapot)
+	})
+	h := WithCORS(policy, next)
+
+	cases := []struct {
+		origin, method string
+		code           int
+		allowOrigin    string
+	}{
+		{"https://app.example.com", http.MethodPatch, http.StatusNoContent, "https://app.example.com"},
+		{"https://ui.example.org", http.MethodGet, http.StatusNoContent, "https://ui.example.org"},
+		{"https://example.org", http.MethodGet, http.StatusForbidden, ""},
+		{"https://evil.com", http.MethodGet, http.StatusForbidden, ""},
+		{"https://app.example.com", http.MethodPut, http.StatusForbidden, "https://app.example.com"},
+	}
+	for _, c := range cases {
+		req := httptest.NewRequest(http.MethodOptions, "/tasks", nil)
+		req.Header.Set("Origin", c.origin)
+		req.Header.Set("Access-Control-Request-Method", c.method)
+		w := httptest.NewRecorder()
+
+		h.ServeHTTP(w, req)
+
+		if w.Code != c.code {
+			t.Errorf("%s %s: expected %d, got %d", c.origin, c.method, c.code, w.Code)
+		}
+		if got := w.Header().Get("Access-Control-Allow-Origin"); got != c.allowOrigin {
+			t.Errorf("%s %s: expected Allow-Origin %q, got %q", c.origin, c.method, c.allowOrigin, got)
+		}
+	}
+
+	// OPTIONS без Access-Control-Request-Method — не preflight, уходит дальше.
+	req := httptest.NewRequest(http.MethodOptions, "/tasks", nil)
+	req.Header.Set("Origin", "https://app.example.com")
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, req)
+	if w.Code != http.StatusTeapot {
+		t.Errorf("expected plain OPTIONS to reach the handler, got %d", w.Code)
+	}
+	if w.Header().Get("Access-Control-Allow-Credentials") != "true" || w.Header().Get("Vary") != "Origin" {
+		t.Errorf("unexpected headers: %v", w.Header())
+	}
+}
@@ -0,0 +1,132 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/icestormerrr/pz3-http/internal/storage"
+)
+
+type addDependencyRequest struct {
+	BlockedBy int64 `json:"blocked_by"`
+}
+
+// POST /tasks/{id}/dependencies — задача id не может быть завершена, пока не завершена blocked_by.
+func (h *Handlers) AddDependency(w http.ResponseWriter, r *http.Request) {
+	id, ok := pathID(w, r, "id")
+	if !ok {
+		return
+	}
+
+	if r.Header.Get("Content-Type") != "" && !strings.Contains(r.Header.Get("Content-Type"), "application/json") {
+		BadRequest(w, "Content-Type must be application/json")
+		return
+	}
+
+	body, ok := readBody(w, r, maxBodySize)
+	if !ok {
+		return
+	}
+	var req addDependencyRequest
+	dec := json.NewDecoder(bytes.NewReader(body))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&req); err != nil {
+		BadRequest(w, "invalid json: "+err.Error())
+		return
+	}
+	if req.BlockedBy <= 0 {
+		BadRequest(w, "blocked_by is required")
+		return
+	}
+
+	match := parseIfMatch(r)
+	if !h.requirePrecondition(w, match) {
+		return
+	}
+
+	t, err := h.Store.AddDependency(id, req.BlockedBy, match)
+	if !writeDependencyError(w, err) {
+		return
+	}
+	w.Header().Set("ETag", taskETag(t))
+	JSON(w, http.StatusOK, t)
+}
+
+// DELETE /tasks/{id}/dependencies/{blocker}
+func (h *Handlers) RemoveDependency(w http.ResponseWriter, r *http.Request) {
+	id, ok := pathID(w, r, "id")
+	if !ok {
+		return
+	}
+	blocker, ok := pathID(w, r, "blocker")
+	if !ok {
+		return
+	}
+
+	match := parseIfMatch(r)
+	if !h.requirePrecondition(w, match) {
+		return
+	}
+
+	t, err := h.Store.RemoveDependency(id, blocker, match)
+	if !writeDependencyError(w, err) {
+		return
+	}
+	w.Header().Set("ETag", taskETag(t))
+	JSON(w, http.StatusOK, t)
+}
+
+// GET /tasks/{id}/graph — дерево всех задач, которые (транзитивно) блокируют данную.
+func (h *Handlers) TaskGraph(w http.ResponseWriter, r *http.Request) {
+	id, ok := pathID(w, r, "id")
+	if !ok {
+		return
+	}
+
+	graph, err := h.Store.Graph(id)
+	if errors.Is(err, storage.ErrNotFound) {
+		NotFound(w, "task not found")
+		return
+	}
+	if err != nil {
+		Internal(w, "unexpected error")
+		return
+	}
+	JSON(w, http.StatusOK, graph)
+}
+
+// writeDependencyError отвечает клиенту, если err не nil, и возвращает false.
+func writeDependencyError(w http.ResponseWriter, err error) bool {
+	switch {
+	case err == nil:
+		return true
+	case errors.Is(err, storage.ErrNotFound):
+		NotFound(w, "task not found")
+	case errors.Is(err, storage.ErrTooManyBlockers):
+		Unprocessable(w, err.Error())
+	case errors.Is(err, storage.ErrBlockerNotFound):
+		Unprocessable(w, "blocker task not found")
+	case errors.Is(err, storage.ErrCycle):
+		Conflict(w, err.Error())
+	case errors.Is(err, storage.ErrVersionMismatch):
+		PreconditionFailed(w, "task was modified, reload it and retry")
+	case errors.As(err, new(*storage.BlockedError)):
+		Conflict(w, err.Error())
+	default:
+		Internal(w, "failed to save task")
+	}
+	return false
+}
+
+func pathID(w http.ResponseWriter, r *http.Request, name string) (int64, bool) {
+	id, err := strconv.ParseInt(r.PathValue(name), 10, 64)
+	if err != nil {
+		BadRequest(w, "invalid "+name)
+		return 0, false
+	}
+	return id, true
+}
@@ -0,0 +1,76 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/icestormerrr/pz3-http/internal/storage"
+)
+
+const sseHeartbeat = 15 * time.Second
+
+// GET /tasks/events — изменения задач в формате text/event-stream.
+// После переподключения браузер присылает Last-Event-ID, и пропущенные события
+// досылаются из истории; если их там уже нет, клиент получает событие reset.
+func (h *Handlers) TaskEvents(w http.ResponseWriter, r *http.Request) {
+	rc := http.NewResponseController(w)
+
+	lastID := r.Header.Get("Last-Event-ID")
+	if lastID == "" {
+		lastID = r.URL.Query().Get("last_event_id")
+	}
+	var since storage.EventID
+	if lastID != "" {
+		var err error
+		if since, err = storage.ParseEventID(lastID); err != nil {
+			BadRequest(w, "invalid Last-Event-ID")
+			return
+		}
+	}
+
+	sub, backlog, complete := h.Store.Events().Subscribe(since)
+	defer sub.Close()
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+
+	fmt.Fprint(w, "retry: 3000\n\n")
+	if !complete {
+		fmt.Fprint(w, "event: reset\ndata: {}\n\n")
+	}
+	for _, e := range backlog {
+		writeEvent(w, e.ID, string(e.Type), e)
+	}
+	if err := rc.Flush(); err != nil {
+		return
+	}
+
+	heartbeat := time.NewTicker(sseHeartbeat)
+	defer heartbeat.Stop()
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-heartbeat.C:
+			fmt.Fprint(w, ": ping\n\n")
+		case e, ok := <-sub.C:
+			// канал закрыт — клиент не успевал читать и был отключён
+			if !ok {
+				return
+			}
+			writeEvent(w, e.ID, string(e.Type), e)
+		}
+		if err := rc.Flush(); err != nil {
+			return
+		}
+	}
+}
+
+func writeEvent(w http.ResponseWriter, id storage.EventID, event string, v any) {
+	data, _ := json.Marshal(v)
+	fmt.Fprintf(w, "id: %s\nevent: %s\ndata: %s\n\n", id, event, data)
+}
@@ -0,0 +1,109 @@
+package api
+
+import (
+	"bufio"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/icestormerrr/pz3-http/internal/storage"
+)
+
+type sseFrame struct {
+	id, event string
+}
+
+// openEvents подключается к потоку и возвращает канал разобранных событий.
+func openEvents(t *testing.T, srv *httptest.Server, lastEventID string) <-chan sseFrame {
+	t.Helper()
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, srv.URL, nil)
+	if lastEventID != "" {
+		req.Header.Set("Last-Event-ID", lastEventID)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("connect: %v", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("expected 200, got %d", resp.StatusCode)
+	}
+
+	frames := make(chan sseFrame, 16)
+	go func() {
+		defer resp.Body.Close()
+		defer close(frames)
+		var f sseFrame
+		scanner := bufio.NewScanner(resp.Body)
+		for scanner.Scan() {
+			line := scanner.Text()
+			switch {
+			case line == "":
+				if f.event != "" {
+					frames <- f
+				}
+				f = sseFrame{}
+			case strings.HasPrefix(line, "id: "):
+				f.id = strings.TrimPrefix(line, "id: ")
+			case strings.HasPrefix(line, "event: "):
+				f.event = strings.TrimPrefix(line, "event: ")
+			}
+		}
+	}()
+	return frames
+}
+
+func nextFrame(t *testing.T, frames <-chan sseFrame) sseFrame {
+	t.Helper()
+	select {
+	case f, ok := <-frames:
+		if !ok {
+			t.Fatal("stream closed")
+		}
+		return f
+	case <-time.After(2 * time.Second):
+		t.Fatal("timed out waiting for an event")
+	}
+	return sseFrame{}
+}
+
+func TestTaskEvents_ResumeAndReset(t *testing.T) {
+	store := storage.NewMemoryStore()
+	h := NewHandlers(store)
+	srv := httptest.NewServer(http.HandlerFunc(h.TaskEvents))
+	defer srv.Close()
+	defer store.Events().Close()
+
+	live := openEvents(t, srv, "")
+	store.Create("First")
+	first := nextFrame(t, live)
+	if first.event != "created" || !strings.Contains(first.id, "-") {
+		t.Fatalf("expected created event with <epoch>-<seq> id, got %+v", first)
+	}
+	store.Create("Second")
+	second := nextFrame(t, live)
+
+	resumed := openEvents(t, srv, first.id)
+	if f := nextFrame(t, resumed); f.id != second.id || f.event != "created" {
+		t.Errorf("expected missed event %s after resume, got %+v", second.id, f)
+	}
+
+	// номер события из другого запуска сервера
+	foreign := openEvents(t, srv, "0123456789ab-1")
+	if f := nextFrame(t, foreign); f.event != "reset" {
+		t.Errorf("expected reset for an id from another epoch, got %+v", f)
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/tasks/events", nil)
+	req.Header.Set("Last-Event-ID", "abc")
+	w := httptest.NewRecorder()
+	h.TaskEvents(w, req)
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("expected 400 for malformed Last-Event-ID, got %d", w.Code)
+	}
+}
@@ -0,0 +1,447 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/graphql-go/graphql"
+	"github.com/graphql-go/graphql/gqlerrors"
+	"github.com/graphql-go/graphql/language/parser"
+	"github.com/graphql-go/graphql/language/source"
+
+	"github.com/icestormerrr/pz3-http/internal/storage"
+)
+
+const defaultPageSize = 20
+
+type GraphQLOptions struct {
+	// Introspection разрешает запросы __schema и __type; в продакшене обычно выключается.
+	Introspection bool
+	MaxDepth      int
+	MaxComplexity int
+}
+
+func DefaultGraphQLOptions() GraphQLOptions {
+	return GraphQLOptions{Introspection: true, MaxDepth: 10, MaxComplexity: 1000}
+}
+
+type graphQLHandler struct {
+	schema graphql.Schema
+	opts   GraphQLOptions
+}
+
+type graphQLRequest struct {
+	Query         string         `json:"query"`
+	OperationName string         `json:"operationName"`
+	Variables     map[string]any `json:"variables"`
+}
+
+// NewGraphQLHandler — POST /graphql поверх того же хранилища и тех же проверок, что и REST.
+func NewGraphQLHandler(h *Handlers, opts GraphQLOptions) (http.Handler, error) {
+	schema, err := newGraphQLSchema(h)
+	if err != nil {
+		return nil, err
+	}
+	return &graphQLHandler{schema: schema, opts: opts}, nil
+}
+
+func (g *graphQLHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Header.Get("Content-Type") != "" && !strings.Contains(r.Header.Get("Content-Type"), "application/json") {
+		BadRequest(w, "Content-Type must be application/json")
+		return
+	}
+
+	body, ok := readBody(w, r, maxBodySize)
+	if !ok {
+		return
+	}
+	var req graphQLRequest
+	if err := json.Unmarshal(body, &req); err != nil {
+		BadRequest(w, "invalid json: "+err.Error())
+		return
+	}
+	if strings.TrimSpace(req.Query) == "" {
+		BadRequest(w, "query is required")
+		return
+	}
+
+	doc, err := parser.Parse(parser.ParseParams{Source: source.NewSource(&source.Source{Body: []byte(req.Query), Name: "GraphQL request"})})
+	if err != nil {
+		JSON(w, http.StatusOK, &graphql.Result{Errors: gqlerrors.FormatErrors(err)})
+		return
+	}
+	if res := graphql.ValidateDocument(&g.schema, doc, nil); !res.IsValid {
+		JSON(w, http.StatusOK, &graphql.Result{Errors: res.Errors})
+		return
+	}
+	if err := checkQueryLimits(doc, req.Variables, g.opts); err != nil {
+		JSON(w, http.StatusOK, &graphql.Result{Errors: gqlerrors.FormatErrors(err)})
+		return
+	}
+
+	result := graphql.Execute(graphql.ExecuteParams{
+		Schema:        g.schema,
+		AST:           doc,
+		OperationName: req.OperationName,
+		Args:          req.Variables,
+		Context:       r.Context(),
+	})
+	JSON(w, http.StatusOK, result)
+}
+
+func newGraphQLSchema(h *Handlers) (graphql.Schema, error) {
+	priority := graphql.NewEnum(graphql.EnumConfig{
+		Name: "Priority",
+		Values: graphql.EnumValueConfigMap{
+			"NONE":   &graphql.EnumValueConfig{Value: string(storage.PriorityNone)},
+			"LOW":    &graphql.EnumValueConfig{Value: string(storage.PriorityLow)},
+			"NORMAL": &graphql.EnumValueConfig{Value: string(storage.PriorityNormal)},
+			"HIGH":   &graphql.EnumValueConfig{Value: string(storage.PriorityHigh)},
+		},
+	})
+
+	// расширения key:value из todo.txt: в GraphQL нет типа-словаря, поэтому список пар по ключу
+	extension := graphql.NewObject(graphql.ObjectConfig{
+		Name: "Extension",
+		Fields: graphql.Fields{
+			"key":   &graphql.Field{Type: graphql.NewNonNull(graphql.String)},
+			"value": &graphql.Field{Type: graphql.NewNonNull(graphql.String)},
+		},
+	})
+	task := graphql.NewObject(graphql.ObjectConfig{Name: "Task", Fields: graphql.Fields{}})
+	task.AddFieldConfig("id", &graphql.Field{Type: graphql.NewNonNull(graphql.ID), Resolve: taskField(func(t *storage.Task) any { return strconv.FormatInt(t.ID, 10) })})
+	task.AddFieldConfig("title", &graphql.Field{Type: graphql.NewNonNull(graphql.String), Resolve: taskField(func(t *storage.Task) any { return t.Title })})
+	task.AddFieldConfig("description", &graphql.Field{Type: graphql.NewNonNull(graphql.String), Resolve: taskField(func(t *storage.Task) any { return t.Description })})
+	task.AddFieldConfig("priority", &graphql.Field{Type: graphql.NewNonNull(priority), Resolve: taskField(func(t *storage.Task) any { return string(t.Priority) })})
+	task.AddFieldConfig("done", &graphql.Field{Type: graphql.NewNonNull(graphql.Boolean), Resolve: taskField(func(t *storage.Task) any { return t.Done })})
+	task.AddFieldConfig("dueDate", &graphql.Field{Type: graphql.DateTime, Resolve: taskField(func(t *storage.Task) any {
+		if t.DueDate == nil {
+			return nil
+		}
+		return *t.DueDate
+	})})
+	task.AddFieldConfig("projects", &graphql.Field{Type: graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(graphql.String))), Resolve: taskField(func(t *storage.Task) any { return append([]string{}, t.Projects...) })})
+	task.AddFieldConfig("contexts", &graphql.Field{Type: graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(graphql.String))), Resolve: taskField(func(t *storage.Task) any { return append([]string{}, t.Contexts...) })})
+	task.AddFieldConfig("completedAt", &graphql.Field{Type: graphql.DateTime, Resolve: taskField(func(t *storage.Task) any {
+		if t.CompletedAt == nil {
+			return nil
+		}
+		return *t.CompletedAt
+	})})
+	task.AddFieldConfig("createdAt", &graphql.Field{Type: graphql.NewNonNull(graphql.DateTime), Resolve: taskField(func(t *storage.Task) any { return t.CreatedAt })})
+	task.AddFieldConfig("updatedAt", &graphql.Field{Type: graphql.NewNonNull(graphql.DateTime), Resolve: taskField(func(t *storage.Task) any { return t.UpdatedAt })})
+	task.AddFieldConfig("version", &graphql.Field{Type: graphql.NewNonNull(graphql.Int), Resolve: taskField(func(t *storage.Task) any { return t.Version })})
+	task.AddFieldConfig("extensions", &graphql.Field{Type: graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(extension))), Resolve: taskField(func(t *storage.Task) any {
+		keys := make([]string, 0, len(t.Extensions))
+		for k := range t.Extensions {
+			keys = append(keys, k)
+		}
+		slices.Sort(keys)
+		out := make([]map[string]any, len(keys))
+		for i, k := range keys {
+			out[i] = map[string]any{"key": k, "value": t.Extensions[k]}
+		}
+		return out
+	})})
+	task.AddFieldConfig("blockedBy", &graphql.Field{
+		Type:    graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(task))),
+		Resolve: taskField(func(t *storage.Task) any { return h.Store.GetMany(t.BlockedBy) }),
+	})
+
+	edge := graphql.NewObject(graphql.ObjectConfig{
+		Name: "TaskEdge",
+		Fields: graphql.Fields{
+			"cursor": &graphql.Field{Type: graphql.NewNonNull(graphql.String)},
+			"node":   &graphql.Field{Type: graphql.NewNonNull(task)},
+		},
+	})
+	pageInfo := graphql.NewObject(graphql.ObjectConfig{
+		Name: "PageInfo",
+		Fields: graphql.Fields{
+			"hasNextPage": &graphql.Field{Type: graphql.NewNonNull(graphql.Boolean)},
+			"endCursor":   &graphql.Field{Type: graphql.String},
+		},
+	})
+	connection := graphql.NewObject(graphql.ObjectConfig{
+		Name: "TaskConnection",
+		Fields: graphql.Fields{
+			"totalCount": &graphql.Field{Type: graphql.NewNonNull(graphql.Int)},
+			"nodes":      &graphql.Field{Type: graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(task)))},
+			"edges":      &graphql.Field{Type: graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(edge)))},
+			"pageInfo":   &graphql.Field{Type: graphql.NewNonNull(pageInfo)},
+		},
+	})
+
+	filter := graphql.NewInputObject(graphql.InputObjectConfig{
+		Name: "TaskFilter",
+		Fields: graphql.InputObjectConfigFieldMap{
+			"q":     &graphql.InputObjectFieldConfig{Type: graphql.String},
+			"done":  &graphql.InputObjectFieldConfig{Type: graphql.Boolean},
+			"ready": &graphql.InputObjectFieldConfig{Type: graphql.Boolean},
+			"sort":  &graphql.InputObjectFieldConfig{Type: graphql.String, Description: "id, title, due, created; префикс - для обратного порядка"},
+		},
+	})
+	createInput := graphql.NewInputObject(graphql.InputObjectConfig{
+		Name: "CreateTaskInput",
+		Fields: graphql.InputObjectConfigFieldMap{
+			"title":       &graphql.InputObjectFieldConfig{Type: graphql.NewNonNull(graphql.String)},
+			"description": &graphql.InputObjectFieldConfig{Type: graphql.String},
+			"priority":    &graphql.InputObjectFieldConfig{Type: priority},
+			"dueDate":     &graphql.InputObjectFieldConfig{Type: graphql.String},
+		},
+	})
+	// null в input-объектах graphql-go не отличает от отсутствия поля,
+	// поэтому срок сбрасывается явным clearDueDate.
+	updateInput := graphql.NewInputObject(graphql.InputObjectConfig{
+		Name: "UpdateTaskInput",
+		Fields: graphql.InputObjectConfigFieldMap{
+			"title":        &graphql.InputObjectFieldConfig{Type: graphql.String},
+			"description":  &graphql.InputObjectFieldConfig{Type: graphql.String},
+			"priority":     &graphql.InputObjectFieldConfig{Type: priority},
+			"done":         &graphql.InputObjectFieldConfig{Type: graphql.Boolean},
+			"dueDate":      &graphql.InputObjectFieldConfig{Type: graphql.String},
+			"clearDueDate": &graphql.InputObjectFieldConfig{Type: graphql.Boolean},
+		},
+	})
+
+	query := graphql.NewObject(graphql.ObjectConfig{
+		Name: "Query",
+		Fields: graphql.Fields{
+			"tasks": &graphql.Field{
+				Type: graphql.NewNonNull(connection),
+				Args: graphql.FieldConfigArgument{
+					"filter": &graphql.ArgumentConfig{Type: filter},
+					"first":  &graphql.ArgumentConfig{Type: graphql.Int, DefaultValue: defaultPageSize},
+					"after":  &graphql.ArgumentConfig{Type: graphql.String},
+				},
+				Resolve: h.resolveTasks,
+			},
+			"task": &graphql.Field{
+				Type: task,
+				Args: graphql.FieldConfigArgument{"id": &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.ID)}},
+				Resolve: func(p graphql.ResolveParams) (any, error) {
+					id, err := graphQLID(p.Args["id"])
+					if err != nil {
+						return nil, err
+					}
+					t, err := h.Store.Get(id)
+					if errors.Is(err, storage.ErrNotFound) {
+						return nil, nil
+					}
+					return t, err
+				},
+			},
+		},
+	})
+
+	mutation := graphql.NewObject(graphql.ObjectConfig{
+		Name: "Mutation",
+		Fields: graphql.Fields{
+			"createTask": &graphql.Field{
+				Type:    graphql.NewNonNull(task),
+				Args:    graphql.FieldConfigArgument{"input": &graphql.ArgumentConfig{Type: graphql.NewNonNull(createInput)}},
+				Resolve: h.resolveCreateTask,
+			},
+			"updateTask": &graphql.Field{
+				Type: graphql.NewNonNull(task),
+				Args: graphql.FieldConfigArgument{
+					"id":      &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.ID)},
+					"input":   &graphql.ArgumentConfig{Type: graphql.NewNonNull(updateInput)},
+					"version": &graphql.ArgumentConfig{Type: graphql.Int, Description: "ожидаемая версия задачи, аналог If-Match"},
+				},
+				Resolve: h.resolveUpdateTask,
+			},
+			"deleteTask": &graphql.Field{
+				Type: graphql.NewNonNull(graphql.Boolean),
+				Args: graphql.FieldConfigArgument{
+					"id":      &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.ID)},
+					"version": &graphql.ArgumentConfig{Type: graphql.Int},
+				},
+				Resolve: h.resolveDeleteTask,
+			},
+		},
+	})
+
+	return graphql.NewSchema(graphql.SchemaConfig{Query: query, Mutation: mutation})
+}
+
+func taskField(fn func(t *storage.Task) any) graphql.FieldResolveFn {
+	return func(p graphql.ResolveParams) (any, error) {
+		t, ok := p.Source.(*storage.Task)
+		if !ok {
+			return nil, nil
+		}
+		return fn(t), nil
+	}
+}
+
+func graphQLID(v any) (int64, error) {
+	s, _ := v.(string)
+	id, err := strconv.ParseInt(s, 10, 64)
+	if err != nil {
+		return 0, errors.New("invalid id")
+	}
+	return id, nil
+}
+
+func (h *Handlers) resolveTasks(p graphql.ResolveParams) (any, error) {
+	q := storage.ListQuery{Limit: defaultPageSize}
+	if f, ok := p.Args["filter"].(map[string]any); ok {
+		q.Query, _ = f["q"].(string)
+		q.Sort, _ = f["sort"].(string)
+		if v, ok := f["done"].(bool); ok {
+			q.Done = &v
+		}
+		if v, ok := f["ready"].(bool); ok {
+			q.Ready = &v
+		}
+	}
+	if first, ok := p.Args["first"].(int); ok {
+		if first < 1 || first > maxListLimit {
+			return nil, errors.New("first must be between 1 and " + strconv.Itoa(maxListLimit))
+		}
+		q.Limit = first
+	}
+	if after, ok := p.Args["after"].(string); ok {
+		cursor, err := storage.DecodeCursor(after)
+		if err != nil {
+			return nil, err
+		}
+		// обратные курсоры из Link: prev в after не подходят
+		if cursor.Backward {
+			return nil, storage.ErrInvalidCursor
+		}
+		q.After = cursor
+	}
+
+	res, err := h.Store.Query(q)
+	if err != nil {
+		return nil, err
+	}
+	sortBy, _ := storage.NormalizeSort(q.Sort)
+
+	edges := make([]map[string]any, len(res.Tasks))
+	var endCursor any
+	for i, t := range res.Tasks {
+		cursor := storage.NewCursor(sortBy, t).Encode()
+		edges[i] = map[string]any{"cursor": cursor, "node": t}
+		endCursor = cursor
+	}
+	return map[string]any{
+		"totalCount": res.Total,
+		"nodes":      res.Tasks,
+		"edges":      edges,
+		"pageInfo":   map[string]any{"hasNextPage": res.Next != nil, "endCursor": endCursor},
+	}, nil
+}
+
+func (h *Handlers) resolveCreateTask(p graphql.ResolveParams) (any, error) {
+	input, _ := p.Args["input"].(map[string]any)
+	req := createTaskRequest{}
+	req.Title, _ = input["title"].(string)
+	req.Description, _ = input["description"].(string)
+	req.Priority, _ = input["priority"].(string)
+	if v, ok := input["dueDate"].(string); ok {
+		req.DueDate = &v
+	}
+
+	in, err := req.toNewTask()
+	if err != nil {
+		return nil, err
+	}
+	return h.Store.CreateTask(in)
+}
+
+func (h *Handlers) resolveUpdateTask(p graphql.ResolveParams) (any, error) {
+	id, err := graphQLID(p.Args["id"])
+	if err != nil {
+		return nil, err
+	}
+	match, err := h.graphQLVersion(p.Args)
+	if err != nil {
+		return nil, err
+	}
+
+	input, _ := p.Args["input"].(map[string]any)
+	var payload storage.TaskUpdatePayload
+	if v, ok := input["title"].(string); ok {
+		title, err := validateTitle(v)
+		if err != nil {
+			return nil, err
+		}
+		payload.Title = &title
+	}
+	if v, ok := input["description"].(string); ok {
+		description, err := validateDescription(v)
+		if err != nil {
+			return nil, err
+		}
+		payload.Description = &description
+	}
+	if v, ok := input["priority"].(string); ok {
+		priority := storage.Priority(v)
+		payload.Priority = &priority
+	}
+	if v, ok := input["done"].(bool); ok {
+		payload.Done = &v
+	}
+	if v, ok := input["dueDate"].(string); ok {
+		if payload.DueDate, err = parseDueDate(v); err != nil {
+			return nil, err
+		}
+	}
+	if v, ok := input["clearDueDate"].(bool); ok && v {
+		payload.ClearDueDate = true
+	}
+
+	t, err := h.Store.UpdateIf(id, match, payload)
+	return t, graphQLStoreError(err)
+}
+
+func (h *Handlers) resolveDeleteTask(p graphql.ResolveParams) (any, error) {
+	id, err := graphQLID(p.Args["id"])
+	if err != nil {
+		return nil, err
+	}
+	match, err := h.graphQLVersion(p.Args)
+	if err != nil {
+		return nil, err
+	}
+
+	err = h.Store.DeleteIf(id, match)
+	if errors.Is(err, storage.ErrNotFound) {
+		return false, nil
+	}
+	if err != nil {
+		return nil, graphQLStoreError(err)
+	}
+	return true, nil
+}
+
+// graphQLVersion — аргумент version работает как If-Match, в том числе в строгом режиме.
+func (h *Handlers) graphQLVersion(args map[string]any) (*storage.VersionMatch, error) {
+	v, ok := args["version"].(int)
+	if !ok {
+		if h.RequireIfMatch {
+			return nil, errors.New("version is required")
+		}
+		return nil, nil
+	}
+	return &storage.VersionMatch{Versions: []int64{int64(v)}}, nil
+}
+
+func graphQLStoreError(err error) error {
+	switch {
+	case err == nil:
+		return nil
+	case errors.Is(err, storage.ErrNotFound):
+		return errors.New("task not found")
+	case errors.Is(err, storage.ErrVersionMismatch):
+		return errors.New("task was modified, reload it and retry")
+	}
+	return err
+}
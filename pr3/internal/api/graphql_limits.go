@@ -0,0 +1,125 @@
+package api
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/graphql-go/graphql/language/ast"
+
+	"github.com/icestormerrr/pz3-http/internal/storage"
+)
+
+// Размер списков без аргумента first — для оценки сложности. Для blockedBy это
+// верхняя граница, которую держит хранилище, а не средний размер.
+var graphQLListSizes = map[string]int{
+	"tasks":     defaultPageSize,
+	"blockedBy": storage.MaxBlockers,
+}
+
+// checkQueryLimits отклоняет слишком глубокие и «дорогие» запросы до выполнения,
+// а также интроспекцию, если она выключена. Поля интроспекции в лимиты не входят:
+// стандартный запрос схемы глубокий, но дешёвый.
+func checkQueryLimits(doc *ast.Document, vars map[string]any, opts GraphQLOptions) error {
+	fragments := map[string]*ast.FragmentDefinition{}
+	for _, def := range doc.Definitions {
+		if f, ok := def.(*ast.FragmentDefinition); ok {
+			fragments[f.Name.Value] = f
+		}
+	}
+
+	w := &limitWalker{fragments: fragments, vars: vars, opts: opts}
+	for _, def := range doc.Definitions {
+		op, ok := def.(*ast.OperationDefinition)
+		if !ok {
+			continue
+		}
+		w.defaults = map[string]ast.Value{}
+		for _, v := range op.VariableDefinitions {
+			if v.DefaultValue != nil {
+				w.defaults[v.Variable.Name.Value] = v.DefaultValue
+			}
+		}
+		depth, cost, err := w.walk(op.SelectionSet, 1)
+		if err != nil {
+			return err
+		}
+		if opts.MaxDepth > 0 && depth > opts.MaxDepth {
+			return fmt.Errorf("query depth %d exceeds limit %d", depth, opts.MaxDepth)
+		}
+		if opts.MaxComplexity > 0 && cost > opts.MaxComplexity {
+			return fmt.Errorf("query complexity %d exceeds limit %d", cost, opts.MaxComplexity)
+		}
+	}
+	return nil
+}
+
+type limitWalker struct {
+	fragments map[string]*ast.FragmentDefinition
+	vars      map[string]any
+	defaults  map[string]ast.Value // значения по умолчанию переменных текущей операции
+	opts      GraphQLOptions
+}
+
+// walk возвращает глубину и стоимость набора полей: каждое поле стоит 1,
+// а стоимость вложенных полей списка умножается на его размер.
+func (w *limitWalker) walk(set *ast.SelectionSet, depth int) (int, int, error) {
+	if set == nil {
+		return depth - 1, 0, nil
+	}
+	maxDepth, cost := depth, 0
+	for _, sel := range set.Selections {
+		var d, c int
+		var err error
+		switch s := sel.(type) {
+		case *ast.Field:
+			name := s.Name.Value
+			if name == "__schema" || name == "__type" {
+				if !w.opts.Introspection {
+					return 0, 0, fmt.Errorf("introspection is disabled")
+				}
+				continue
+			}
+			d, c, err = w.walk(s.SelectionSet, depth+1)
+			c = 1 + c*w.listSize(s)
+		case *ast.InlineFragment:
+			d, c, err = w.walk(s.SelectionSet, depth)
+		case *ast.FragmentSpread:
+			// циклы фрагментов уже отсеяны стандартной валидацией
+			if f, ok := w.fragments[s.Name.Value]; ok {
+				d, c, err = w.walk(f.SelectionSet, depth)
+			}
+		}
+		if err != nil {
+			return 0, 0, err
+		}
+		maxDepth = max(maxDepth, d)
+		cost += c
+	}
+	return maxDepth, cost, nil
+}
+
+func (w *limitWalker) listSize(f *ast.Field) int {
+	size, ok := graphQLListSizes[f.Name.Value]
+	if !ok {
+		return 1
+	}
+	for _, arg := range f.Arguments {
+		if arg.Name.Value != "first" {
+			continue
+		}
+		value := arg.Value
+		if v, ok := value.(*ast.Variable); ok {
+			if n, ok := w.vars[v.Name.Value].(float64); ok {
+				size = int(n)
+				continue
+			}
+			value = w.defaults[v.Name.Value]
+		}
+		if v, ok := value.(*ast.IntValue); ok {
+			if n, err := strconv.Atoi(v.Value); err == nil {
+				size = n
+			}
+		}
+	}
+	return max(size, 1)
+}
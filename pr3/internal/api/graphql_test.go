@@ -0,0 +1,151 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/icestormerrr/pz3-http/internal/storage"
+)
+
+func TestGraphQL_CreateAndLimits(t *testing.T) {
+	opts := DefaultGraphQLOptions()
+	opts.Introspection = false
+	opts.MaxDepth = 3
+	gql, err := NewGraphQLHandler(NewHandlers(storage.NewMemoryStore()), opts)
+	if err != nil {
+		t.Fatalf("schema: %v", err)
+	}
+
+	exec := func(query string) map[string]any {
+		body, _ := json.Marshal(map[string]string{"query": query})
+		req := httptest.NewRequest(http.MethodPost, "/graphql", bytes.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		gql.ServeHTTP(w, req)
+		if w.Code != http.StatusOK {
+			t.Fatalf("expected 200, got %d", w.Code)
+		}
+		var res map[string]any
+		if err := json.Unmarshal(w.Body.Bytes(), &res); err != nil {
+			t.Fatalf("invalid JSON: %v", err)
+		}
+		return res
+	}
+
+	res := exec(`mutation { createTask(input: {title: "Buy milk"}) { id title } }`)
+	if res["errors"] != nil {
+		t.Fatalf("unexpected errors: %v", res["errors"])
+	}
+
+	res = exec(`{ tasks(filter: {q: "milk"}) { totalCount nodes { title } } }`)
+	tasks := res["data"].(map[string]any)["tasks"].(map[string]any)
+	if tasks["totalCount"] != float64(1) {
+		t.Errorf("expected one task, got %v", tasks)
+	}
+
+	if res := exec(`mutation { createTask(input: {title: "a"}) { id } }`); res["errors"] == nil {
+		t.Errorf("expected title validation error")
+	}
+	if res := exec(`{ __schema { types { name } } }`); res["errors"] == nil {
+		t.Errorf("expected introspection to be rejected")
+	}
+	if res := exec(`{ tasks { nodes { blockedBy { id } } } }`); res["errors"] == nil {
+		t.Errorf("expected depth limit error")
+	}
+}
+
+func TestGraphQL_ExtensionsCursorsAndBodyLimit(t *testing.T) {
+	store := storage.NewMemoryStore()
+	store.CreateTask(storage.NewTask{Title: "Design", Extensions: map[string]string{"owner": "ann", "est": "2h"}})
+	store.Create("Build")
+	store.AddDependency(2, 1, nil)
+	opts := DefaultGraphQLOptions()
+	opts.MaxComplexity = 200
+	gql, err := NewGraphQLHandler(NewHandlers(store), opts)
+	if err != nil {
+		t.Fatalf("schema: %v", err)
+	}
+
+	exec := func(query string, vars map[string]any) map[string]any {
+		body, _ := json.Marshal(map[string]any{"query": query, "variables": vars})
+		req := httptest.NewRequest(http.MethodPost, "/graphql", bytes.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		gql.ServeHTTP(w, req)
+		var res map[string]any
+		if err := json.Unmarshal(w.Body.Bytes(), &res); err != nil {
+			t.Fatalf("invalid JSON: %v", err)
+		}
+		return res
+	}
+
+	res := exec(`{ task(id: "2") { blockedBy { title extensions { key value } } } }`, nil)
+	data, _ := json.Marshal(res["data"])
+	want := `{"task":{"blockedBy":[{"extensions":[{"key":"est","value":"2h"},{"key":"owner","value":"ann"}],"title":"Design"}]}}`
+	if string(data) != want {
+		t.Errorf("unexpected result: %s", data)
+	}
+
+	// first берётся из значения по умолчанию, если переменная не передана
+	query := `query($n: Int = 100) { tasks(first: $n) { nodes { id } } }`
+	if res := exec(query, nil); res["errors"] == nil {
+		t.Errorf("expected complexity error for default first = 100")
+	}
+	if res := exec(query, map[string]any{"n": 5}); res["errors"] != nil {
+		t.Errorf("unexpected errors: %v", res["errors"])
+	}
+
+	task, _ := store.Get(2)
+	prev := storage.NewCursor("id", task)
+	prev.Backward = true
+	if res := exec(`query($c: String) { tasks(after: $c) { totalCount } }`, map[string]any{"c": prev.Encode()}); res["errors"] == nil {
+		t.Errorf("expected backward cursor to be rejected in after")
+	}
+
+	big, _ := json.Marshal(map[string]string{"query": "{ tasks { totalCount } }", "pad": strings.Repeat("x", maxBodySize)})
+	req := httptest.NewRequest(http.MethodPost, "/graphql", bytes.NewReader(big))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	gql.ServeHTTP(w, req)
+	if w.Code != http.StatusRequestEntityTooLarge {
+		t.Errorf("expected 413 for oversized body, got %d", w.Code)
+	}
+}
+
+func TestGraphQL_BlockedByCostUsesBlockerLimit(t *testing.T) {
+	store := storage.NewMemoryStore()
+	h := NewHandlers(store)
+	for i := 0; i <= storage.MaxBlockers; i++ {
+		store.Create("Task " + strconv.Itoa(i))
+	}
+	for i := 2; i <= storage.MaxBlockers+1; i++ {
+		if _, err := store.AddDependency(1, int64(i), nil); err != nil {
+			t.Fatalf("add blocker %d: %v", i, err)
+		}
+	}
+	if _, err := store.AddDependency(1, int64(storage.MaxBlockers+1), nil); err != nil {
+		t.Errorf("expected re-adding an existing blocker to succeed: %v", err)
+	}
+	extra, _ := store.Create("One too many")
+	if _, err := store.AddDependency(1, extra.ID, nil); !errors.Is(err, storage.ErrTooManyBlockers) {
+		t.Errorf("expected ErrTooManyBlockers, got %v", err)
+	}
+
+	gql, err := NewGraphQLHandler(h, DefaultGraphQLOptions())
+	if err != nil {
+		t.Fatalf("schema: %v", err)
+	}
+	body, _ := json.Marshal(map[string]string{"query": `{ task(id: "1") { blockedBy { blockedBy { blockedBy { id } } } } }`})
+	req := httptest.NewRequest(http.MethodPost, "/graphql", bytes.NewReader(body))
+	w := httptest.NewRecorder()
+	gql.ServeHTTP(w, req)
+	if !strings.Contains(w.Body.String(), "complexity") {
+		t.Errorf("expected nested blockedBy to exceed the complexity limit, got %s", w.Body.String())
+	}
+}
@@ -3,7 +3,9 @@ package api
 import (
 	"encoding/json"
 	"errors"
+	"io"
 	"net/http"
+	"net/url"
 	"strconv"
 	"strings"
 
@@ -12,33 +14,138 @@ import (
 
 type Handlers struct {
 	Store *storage.MemoryStore
+	// RequireIfMatch включает строгий режим: PATCH и DELETE без If-Match получают 428.
+	RequireIfMatch bool
 }
 
 func NewHandlers(store *storage.MemoryStore) *Handlers {
 	return &Handlers{Store: store}
 }
 
-// GET /tasks
+const (
+	maxListLimit = 100
+	// maxBodySize ограничивает JSON-тела запросов; более длинные получают 413
+	maxBodySize = 1 << 20
+)
+
+// GET /tasks?q=&done=&ready=&sort=&limit=&offset=|cursor=
 func (h *Handlers) ListTasks(w http.ResponseWriter, r *http.Request) {
-	tasks := h.Store.List()
-
-	// Поддержка простых фильтров через query: ?q=text
-	q := strings.TrimSpace(r.URL.Query().Get("q"))
-	if q != "" {
-		filtered := tasks[:0]
-		for _, t := range tasks {
-			if strings.Contains(strings.ToLower(t.Title), strings.ToLower(q)) {
-				filtered = append(filtered, t)
-			}
+	query := r.URL.Query()
+	q := storage.ListQuery{
+		Query: strings.TrimSpace(query.Get("q")),
+		Sort:  query.Get("sort"),
+	}
+
+	if v := query.Get("done"); v != "" {
+		done, err := strconv.ParseBool(v)
+		if err != nil {
+			BadRequest(w, "done must be true or false")
+			return
+		}
+		q.Done = &done
+	}
+
+	if v := query.Get("ready"); v != "" {
+		ready, err := strconv.ParseBool(v)
+		if err != nil {
+			BadRequest(w, "ready must be true or false")
+			return
+		}
+		q.Ready = &ready
+	}
+
+	if v := query.Get("limit"); v != "" {
+		limit, err := strconv.Atoi(v)
+		if err != nil || limit < 1 || limit > maxListLimit {
+			BadRequest(w, "limit must be between 1 and "+strconv.Itoa(maxListLimit))
+			return
+		}
+		q.Limit = limit
+	}
+
+	if v := query.Get("offset"); v != "" {
+		offset, err := strconv.Atoi(v)
+		if err != nil || offset < 0 {
+			BadRequest(w, "offset must be a non-negative integer")
+			return
+		}
+		q.Offset = offset
+	}
+
+	// пустой cursor= включает курсорный режим с первой страницы
+	cursorMode := query.Has("cursor")
+	if cursorMode && query.Has("offset") {
+		BadRequest(w, "cursor and offset cannot be used together")
+		return
+	}
+	if v := query.Get("cursor"); v != "" {
+		cursor, err := storage.DecodeCursor(v)
+		if err != nil {
+			BadRequest(w, "invalid cursor")
+			return
 		}
-		tasks = filtered
+		if cursor.Backward {
+			q.Before = cursor
+		} else {
+			q.After = cursor
+		}
+	}
+
+	res, err := h.Store.Query(q)
+	switch {
+	case errors.Is(err, storage.ErrInvalidSort):
+		BadRequest(w, "sort must be one of id, title, due, created, optionally prefixed with -")
+		return
+	case errors.Is(err, storage.ErrInvalidCursor):
+		BadRequest(w, "cursor does not match sort")
+		return
+	case err != nil:
+		Internal(w, "failed to list tasks")
+		return
 	}
 
-	JSON(w, http.StatusOK, tasks)
+	w.Header().Set("X-Total-Count", strconv.Itoa(res.Total))
+	if links := paginationLinks(r, q, res, cursorMode); len(links) > 0 {
+		w.Header().Set("Link", strings.Join(links, ", "))
+	}
+	JSON(w, http.StatusOK, res.Tasks)
 }
 
-type createTaskRequest struct {
-	Title string `json:"title"`
+// paginationLinks строит ссылки next/prev по RFC 8288, сохраняя остальные параметры запроса.
+// В курсорном режиме prev ведёт на курсор «перед первой задачей страницы».
+func paginationLinks(r *http.Request, q storage.ListQuery, res storage.ListResult, cursorMode bool) []string {
+	if q.Limit == 0 {
+		return nil
+	}
+	link := func(rel string, set map[string]string) string {
+		values := r.URL.Query()
+		values.Del("offset")
+		values.Del("cursor")
+		for k, v := range set {
+			values.Set(k, v)
+		}
+		u := url.URL{Path: r.URL.Path, RawQuery: values.Encode()}
+		return "<" + u.String() + `>; rel="` + rel + `"`
+	}
+
+	var links []string
+	if cursorMode {
+		if res.Next != nil {
+			links = append(links, link("next", map[string]string{"cursor": res.Next.Encode()}))
+		}
+		if res.Prev != nil {
+			links = append(links, link("prev", map[string]string{"cursor": res.Prev.Encode()}))
+		}
+		return append(links, link("first", map[string]string{"cursor": ""}))
+	}
+
+	if res.Next != nil {
+		links = append(links, link("next", map[string]string{"offset": strconv.Itoa(q.Offset + q.Limit)}))
+	}
+	if q.Offset > 0 {
+		links = append(links, link("prev", map[string]string{"offset": strconv.Itoa(max(q.Offset-q.Limit, 0))}))
+	}
+	return links
 }
 
 func (h *Handlers) CreateTask(w http.ResponseWriter, r *http.Request) {
@@ -47,28 +154,27 @@ func (h *Handlers) CreateTask(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	var req createTaskRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		BadRequest(w, "invalid json: "+err.Error())
+	body, ok := readBody(w, r, maxBodySize)
+	if !ok {
 		return
 	}
-	req.Title = strings.TrimSpace(req.Title)
-	if req.Title == "" {
-		BadRequest(w, "title is required")
+	var req createTaskRequest
+	if err := json.Unmarshal(body, &req); err != nil {
+		BadRequest(w, "invalid json: "+err.Error())
 		return
 	}
-
-	if len(req.Title) < 3 {
-		Unprocessable(w, "title is too short")
+	in, err := req.toNewTask()
+	if err != nil {
+		writeValidationError(w, err)
 		return
 	}
 
-	if len(req.Title) > 140 {
-		Unprocessable(w, "title is too long")
+	t, err := h.Store.CreateTask(in)
+	if err != nil {
+		Internal(w, "failed to save task")
 		return
 	}
-
-	t := h.Store.Create(req.Title)
+	w.Header().Set("ETag", taskETag(t))
 	JSON(w, http.StatusCreated, t)
 }
 
@@ -81,29 +187,35 @@ func (h *Handlers) GetTask(w http.ResponseWriter, r *http.Request) {
 
 	t, err := h.Store.Get(id)
 	if err != nil {
-		if err.Error() == "not found" {
+		if errors.Is(err, storage.ErrNotFound) {
 			NotFound(w, "task not found")
 			return
 		}
 		Internal(w, "unexpected error")
 		return
 	}
-	JSON(w, http.StatusOK, t)
-}
 
-type updateTaskRequest struct {
-	Done bool `json:"done"`
+	etag := taskETag(t)
+	w.Header().Set("ETag", etag)
+	if ifNoneMatch(r, etag) {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+	JSON(w, http.StatusOK, t)
 }
 
+// PATCH /tasks/{id} — JSON Merge Patch (RFC 7396). Обычный application/json
+// трактуется так же, чтобы старые клиенты с {"done":true} продолжали работать.
 func (h *Handlers) UpdateTask(w http.ResponseWriter, r *http.Request) {
-	if r.Header.Get("Content-Type") != "" && !strings.Contains(r.Header.Get("Content-Type"), "application/json") {
-		BadRequest(w, "Content-Type must be application/json")
+	ct := r.Header.Get("Content-Type")
+	if ct != "" && !strings.Contains(ct, "application/merge-patch+json") && !strings.Contains(ct, "application/json") {
+		w.Header().Set("Accept-Patch", "application/merge-patch+json, application/json")
+		UnsupportedMediaType(w, "Content-Type must be application/merge-patch+json or application/json")
 		return
 	}
 
-	var req updateTaskRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		BadRequest(w, "invalid json: "+err.Error())
+	body, ok := readBody(w, r, maxBodySize)
+	if !ok {
 		return
 	}
 
@@ -112,12 +224,36 @@ func (h *Handlers) UpdateTask(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	t := h.Store.Update(id, storage.TaskUpdatePayload{Done: req.Done})
-	if t == nil {
+	match := parseIfMatch(r)
+	if !h.requirePrecondition(w, match) {
+		return
+	}
+
+	payload, err := parseMergePatch(body)
+	if err != nil {
+		writeValidationError(w, err)
+		return
+	}
+
+	t, err := h.Store.UpdateIf(id, match, payload)
+	if errors.Is(err, storage.ErrNotFound) {
 		NotFound(w, "task not found")
 		return
 	}
+	if errors.Is(err, storage.ErrVersionMismatch) {
+		PreconditionFailed(w, "task was modified, reload it and retry")
+		return
+	}
+	if errors.As(err, new(*storage.BlockedError)) || errors.As(err, new(*storage.DoneDependentsError)) {
+		Conflict(w, err.Error())
+		return
+	}
+	if err != nil {
+		Internal(w, "failed to save task")
+		return
+	}
 
+	w.Header().Set("ETag", taskETag(t))
 	JSON(w, http.StatusOK, t)
 }
 
@@ -127,10 +263,40 @@ func (h *Handlers) DeleteTask(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	h.Store.Delete(id)
+	match := parseIfMatch(r)
+	if !h.requirePrecondition(w, match) {
+		return
+	}
+
+	err = h.Store.DeleteIf(id, match)
+	if errors.Is(err, storage.ErrVersionMismatch) {
+		PreconditionFailed(w, "task was modified, reload it and retry")
+		return
+	}
+	// удаление без условия идемпотентно: отсутствующая задача — не ошибка
+	if err != nil && !errors.Is(err, storage.ErrNotFound) {
+		Internal(w, "failed to delete task")
+		return
+	}
 	w.WriteHeader(http.StatusOK)
 }
 
+// readBody читает тело целиком, но не больше limit байт: более длинное тело
+// отклоняется с 413, а не обрезается молча. При ошибке ответ уже записан.
+func readBody(w http.ResponseWriter, r *http.Request, limit int64) ([]byte, bool) {
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, limit))
+	var tooLarge *http.MaxBytesError
+	switch {
+	case errors.As(err, &tooLarge):
+		PayloadTooLarge(w, "request body is too large (max "+strconv.FormatInt(limit, 10)+" bytes)")
+		return nil, false
+	case err != nil:
+		BadRequest(w, "failed to read body")
+		return nil, false
+	}
+	return body, true
+}
+
 func extractIDFromPath(w http.ResponseWriter, r *http.Request) (int64, error) {
 	parts := strings.Split(strings.Trim(r.URL.Path, "/"), "/")
 	if len(parts) != 2 {
@@ -3,10 +3,14 @@ package api
 import (
 	"bytes"
 	"encoding/json"
+	"fmt"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
+	"time"
 
+	"github.com/icestormerrr/pz3-http/internal/idempotency"
 	"github.com/icestormerrr/pz3-http/internal/storage"
 )
 
@@ -51,6 +55,28 @@ func TestCreateTask_TooShort(t *testing.T) {
 	}
 }
 
+func TestCreateTask_TitleLengthInCharacters(t *testing.T) {
+	store := storage.NewMemoryStore()
+	h := NewHandlers(store)
+
+	for title, want := range map[string]int{
+		"Яя":                     http.StatusUnprocessableEntity,
+		"Ясно":                   http.StatusCreated,
+		strings.Repeat("ж", 140): http.StatusCreated,
+		strings.Repeat("ж", 141): http.StatusUnprocessableEntity,
+	} {
+		body, _ := json.Marshal(map[string]string{"title": title})
+		req := httptest.NewRequest(http.MethodPost, "/tasks", bytes.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		h.CreateTask(w, req)
+
+		if w.Code != want {
+			t.Errorf("title of %d runes: expected %d, got %d", len([]rune(title)), want, w.Code)
+		}
+	}
+}
+
 func TestListTasks_Filter(t *testing.T) {
 	store := storage.NewMemoryStore()
 	store.Create("Buy milk")
@@ -75,3 +101,466 @@ func TestListTasks_Filter(t *testing.T) {
 		t.Errorf("filter failed, got %v", tasks)
 	}
 }
+
+func TestUpdateTask_MergePatchKeepsOmittedFields(t *testing.T) {
+	store := storage.NewMemoryStore()
+	store.CreateTask(storage.NewTask{Title: "Buy milk", Description: "2 liters", Priority: storage.PriorityHigh})
+	h := NewHandlers(store)
+
+	body := bytes.NewBufferString(`{"done":true,"description":null}`)
+	req := httptest.NewRequest(http.MethodPatch, "/tasks/1", body)
+	req.Header.Set("Content-Type", "application/merge-patch+json")
+	w := httptest.NewRecorder()
+
+	h.UpdateTask(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+
+	task, _ := store.Get(1)
+	if !task.Done || task.Description != "" {
+		t.Errorf("expected done and cleared description, got %+v", task)
+	}
+	if task.Title != "Buy milk" || task.Priority != storage.PriorityHigh {
+		t.Errorf("expected omitted fields to stay unchanged, got %+v", task)
+	}
+}
+
+func TestUpdateTask_MergePatchValidatesTitle(t *testing.T) {
+	store := storage.NewMemoryStore()
+	store.Create("Buy milk")
+	h := NewHandlers(store)
+
+	cases := map[string]int{
+		`{"title":"a"}`:   http.StatusUnprocessableEntity,
+		`{"title":null}`:  http.StatusUnprocessableEntity,
+		`{"id":5}`:        http.StatusUnprocessableEntity,
+		`{"color":"red"}`: http.StatusBadRequest,
+		`[{"done":true}]`: http.StatusBadRequest,
+	}
+	for payload, code := range cases {
+		req := httptest.NewRequest(http.MethodPatch, "/tasks/1", bytes.NewBufferString(payload))
+		req.Header.Set("Content-Type", "application/merge-patch+json")
+		w := httptest.NewRecorder()
+
+		h.UpdateTask(w, req)
+
+		if w.Code != code {
+			t.Errorf("%s: expected %d, got %d", payload, code, w.Code)
+		}
+	}
+
+	task, _ := store.Get(1)
+	if task.Title != "Buy milk" {
+		t.Errorf("expected title to stay unchanged, got %q", task.Title)
+	}
+}
+
+func TestListTasks_SortAndPaginate(t *testing.T) {
+	store := storage.NewMemoryStore()
+	for _, title := range []string{"Delta", "alpha", "Charlie", "bravo"} {
+		store.Create(title)
+	}
+	done := true
+	store.Update(3, storage.TaskUpdatePayload{Done: &done})
+
+	h := NewHandlers(store)
+	req := httptest.NewRequest(http.MethodGet, "/tasks?done=false&sort=title&limit=2", nil)
+	w := httptest.NewRecorder()
+
+	h.ListTasks(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", w.Code)
+	}
+	if got := w.Header().Get("X-Total-Count"); got != "3" {
+		t.Errorf("expected X-Total-Count 3, got %q", got)
+	}
+
+	var tasks []map[string]any
+	if err := json.Unmarshal(w.Body.Bytes(), &tasks); err != nil {
+		t.Fatalf("invalid JSON: %v", err)
+	}
+	if len(tasks) != 2 || tasks[0]["title"] != "alpha" || tasks[1]["title"] != "bravo" {
+		t.Errorf("unexpected first page: %v", tasks)
+	}
+
+	link := w.Header().Get("Link")
+	want := `</tasks?done=false&limit=2&offset=2&sort=title>; rel="next"`
+	if link != want {
+		t.Errorf("expected Link %q, got %q", want, link)
+	}
+}
+
+func TestListTasks_CursorPrevNext(t *testing.T) {
+	store := storage.NewMemoryStore()
+	for _, title := range []string{"one", "two", "three", "four", "five"} {
+		store.Create(title)
+	}
+	h := NewHandlers(store)
+
+	get := func(target string) ([]int64, map[string]string) {
+		t.Helper()
+		w := httptest.NewRecorder()
+		h.ListTasks(w, httptest.NewRequest(http.MethodGet, target, nil))
+		if w.Code != http.StatusOK {
+			t.Fatalf("%s: expected 200, got %d", target, w.Code)
+		}
+		var tasks []storage.Task
+		json.Unmarshal(w.Body.Bytes(), &tasks)
+		ids := make([]int64, len(tasks))
+		for i, task := range tasks {
+			ids[i] = task.ID
+		}
+		links := map[string]string{}
+		for _, l := range strings.Split(w.Header().Get("Link"), ", ") {
+			if target, rel, ok := strings.Cut(l, `>; rel="`); ok {
+				links[strings.TrimSuffix(rel, `"`)] = strings.TrimPrefix(target, "<")
+			}
+		}
+		return ids, links
+	}
+
+	ids, links := get("/tasks?limit=2&cursor=")
+	if fmt.Sprint(ids) != "[1 2]" || links["prev"] != "" {
+		t.Fatalf("unexpected first page %v, links %v", ids, links)
+	}
+	ids, links = get(links["next"])
+	if fmt.Sprint(ids) != "[3 4]" {
+		t.Fatalf("unexpected second page %v", ids)
+	}
+	ids, links = get(links["next"])
+	if fmt.Sprint(ids) != "[5]" || links["next"] != "" {
+		t.Fatalf("unexpected last page %v, links %v", ids, links)
+	}
+	ids, links = get(links["prev"])
+	if fmt.Sprint(ids) != "[3 4]" {
+		t.Fatalf("expected prev to return the second page, got %v", ids)
+	}
+	ids, _ = get(links["prev"])
+	if fmt.Sprint(ids) != "[1 2]" {
+		t.Fatalf("expected prev to return the first page, got %v", ids)
+	}
+}
+
+func TestUpdateTask_IfMatch(t *testing.T) {
+	store := storage.NewMemoryStore()
+	store.Create("Buy milk")
+	h := NewHandlers(store)
+	h.RequireIfMatch = true
+
+	patch := func(ifMatch string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPatch, "/tasks/1", bytes.NewBufferString(`{"done":true}`))
+		req.Header.Set("Content-Type", "application/merge-patch+json")
+		if ifMatch != "" {
+			req.Header.Set("If-Match", ifMatch)
+		}
+		w := httptest.NewRecorder()
+		h.UpdateTask(w, req)
+		return w
+	}
+
+	if w := patch(""); w.Code != http.StatusPreconditionRequired {
+		t.Fatalf("expected 428 without If-Match, got %d", w.Code)
+	}
+
+	w := patch(`"1"`)
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	if etag := w.Header().Get("ETag"); etag != `"2"` {
+		t.Errorf("expected ETag \"2\", got %s", etag)
+	}
+
+	if w := patch(`"1"`); w.Code != http.StatusPreconditionFailed {
+		t.Errorf("expected 412 for stale version, got %d", w.Code)
+	}
+}
+
+func TestBatchTasks_AtomicRollback(t *testing.T) {
+	store := storage.NewMemoryStore()
+	store.Create("Buy milk")
+	h := NewHandlers(store)
+
+	body := bytes.NewBufferString(`{"operations":[
+		{"op":"create","task":{"title":"Write code"}},
+		{"op":"update","id":1,"patch":{"done":true}},
+		{"op":"delete","id":1,"if_match":"\"7\""}
+	]}`)
+	req := httptest.NewRequest(http.MethodPost, "/tasks:batch", body)
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+
+	h.BatchTasks(w, req)
+
+	if w.Code != http.StatusPreconditionFailed {
+		t.Fatalf("expected 412, got %d: %s", w.Code, w.Body.String())
+	}
+
+	var resp batchResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("invalid JSON: %v", err)
+	}
+	if resp.Applied || resp.Results[0].Status != http.StatusFailedDependency || resp.Results[2].Status != http.StatusPreconditionFailed {
+		t.Errorf("unexpected results: %+v", resp)
+	}
+
+	tasks := store.List()
+	if len(tasks) != 1 || tasks[0].Done {
+		t.Errorf("expected store to stay unchanged, got %+v", tasks)
+	}
+}
+
+func TestCreateTask_IdempotencyKey(t *testing.T) {
+	store := storage.NewMemoryStore()
+	h := WithIdempotency(idempotency.NewMemoryStore(time.Hour, 0), http.HandlerFunc(NewHandlers(store).CreateTask))
+
+	post := func(body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/tasks", bytes.NewBufferString(body))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Idempotency-Key", "abc-123")
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, req)
+		return w
+	}
+
+	first := post(`{"title":"Buy milk"}`)
+	if first.Code != http.StatusCreated {
+		t.Fatalf("expected 201, got %d", first.Code)
+	}
+
+	retry := post(`{"title":"Buy milk"}`)
+	if retry.Code != http.StatusCreated || retry.Body.String() != first.Body.String() {
+		t.Errorf("expected replayed response, got %d %s", retry.Code, retry.Body.String())
+	}
+	if retry.Header().Get("Idempotent-Replayed") != "true" {
+		t.Errorf("expected Idempotent-Replayed header")
+	}
+
+	if w := post(`{"title":"Buy bread"}`); w.Code != http.StatusUnprocessableEntity {
+		t.Errorf("expected 422 for reused key with different body, got %d", w.Code)
+	}
+
+	if n := len(store.List()); n != 1 {
+		t.Errorf("expected exactly one task, got %d", n)
+	}
+}
+
+func TestCreateTask_IdempotencyKeyRejectsOversizedBody(t *testing.T) {
+	store := storage.NewMemoryStore()
+	h := WithIdempotency(idempotency.NewMemoryStore(time.Hour, 0), http.HandlerFunc(NewHandlers(store).CreateTask))
+
+	post := func(body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/tasks", bytes.NewBufferString(body))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Idempotency-Key", "big")
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, req)
+		return w
+	}
+
+	padding := strings.Repeat(" ", maxBodySize)
+	if w := post(`{"title":"Buy milk"}` + padding); w.Code != http.StatusRequestEntityTooLarge {
+		t.Fatalf("expected 413, got %d", w.Code)
+	}
+	// ключ не был занят отклонённым запросом
+	if w := post(`{"title":"Buy milk"}`); w.Code != http.StatusCreated {
+		t.Errorf("expected 201 after oversized attempt, got %d", w.Code)
+	}
+}
+
+func TestDependencies_CycleAndBlockedDone(t *testing.T) {
+	store := storage.NewMemoryStore()
+	store.Create("Design")
+	store.Create("Build")
+	h := NewHandlers(store)
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /tasks/{id}/dependencies", h.AddDependency)
+	mux.HandleFunc("PATCH /tasks/", h.UpdateTask)
+
+	do := func(method, path, body string) int {
+		req := httptest.NewRequest(method, path, bytes.NewBufferString(body))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		mux.ServeHTTP(w, req)
+		return w.Code
+	}
+
+	if code := do(http.MethodPost, "/tasks/2/dependencies", `{"blocked_by":1}`); code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", code)
+	}
+	if code := do(http.MethodPost, "/tasks/1/dependencies", `{"blocked_by":2}`); code != http.StatusConflict {
+		t.Errorf("expected 409 for cycle, got %d", code)
+	}
+	if code := do(http.MethodPatch, "/tasks/2", `{"done":true}`); code != http.StatusConflict {
+		t.Errorf("expected 409 while blocker is open, got %d", code)
+	}
+	if code := do(http.MethodPatch, "/tasks/1", `{"done":true}`); code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", code)
+	}
+	if code := do(http.MethodPatch, "/tasks/2", `{"done":true}`); code != http.StatusOK {
+		t.Errorf("expected 200 once blocker is done, got %d", code)
+	}
+	if code := do(http.MethodPatch, "/tasks/1", `{"done":false}`); code != http.StatusConflict {
+		t.Errorf("expected 409 when reopening a blocker of a done task, got %d", code)
+	}
+	if code := do(http.MethodPatch, "/tasks/2", `{"done":false}`); code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", code)
+	}
+	if code := do(http.MethodPatch, "/tasks/1", `{"done":false}`); code != http.StatusOK {
+		t.Errorf("expected 200 once the dependent is reopened, got %d", code)
+	}
+}
+
+func TestImportExportTodoTxt(t *testing.T) {
+	store := storage.NewMemoryStore()
+	h := NewHandlers(store)
+
+	input := "(A) 2024-01-10 Call Mom +family @phone due:2024-01-20\n" +
+		"x 2024-01-12 2024-01-01 Pay rent +home pri:B\n"
+	req := httptest.NewRequest(http.MethodPost, "/tasks/import", bytes.NewBufferString(input))
+	w := httptest.NewRecorder()
+	h.ImportTasks(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	task, _ := store.Get(2)
+	if !task.Done || task.Priority != storage.PriorityNormal || task.CompletedAt == nil {
+		t.Errorf("unexpected imported task: %+v", task)
+	}
+
+	req = httptest.NewRequest(http.MethodGet, "/tasks/export?format=todotxt", nil)
+	w = httptest.NewRecorder()
+	h.ExportTasks(w, req)
+
+	if w.Body.String() != input {
+		t.Errorf("expected export to round-trip, got:\n%s", w.Body.String())
+	}
+
+	req = httptest.NewRequest(http.MethodPost, "/tasks/import", bytes.NewBufferString("Fine task\n\nx\n"))
+	w = httptest.NewRecorder()
+	h.ImportTasks(w, req)
+
+	var resp importResponse
+	json.Unmarshal(w.Body.Bytes(), &resp)
+	if w.Code != http.StatusUnprocessableEntity || len(resp.Errors) != 1 || resp.Errors[0].Line != 3 {
+		t.Errorf("expected error on line 3, got %d %+v", w.Code, resp)
+	}
+	if n := len(store.List()); n != 2 {
+		t.Errorf("expected atomic import to create nothing, got %d tasks", n)
+	}
+}
+
+func TestAddDependency_OpenBlockerOnDoneTaskAndStrictBody(t *testing.T) {
+	store := storage.NewMemoryStore()
+	store.Create("Design")
+	store.Create("Build")
+	done := true
+	store.Update(2, storage.TaskUpdatePayload{Done: &done})
+	h := NewHandlers(store)
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /tasks/{id}/dependencies", h.AddDependency)
+
+	do := func(body, contentType string) int {
+		req := httptest.NewRequest(http.MethodPost, "/tasks/2/dependencies", bytes.NewBufferString(body))
+		req.Header.Set("Content-Type", contentType)
+		w := httptest.NewRecorder()
+		mux.ServeHTTP(w, req)
+		return w.Code
+	}
+
+	if code := do(`{"blocked_by":1}`, "application/json"); code != http.StatusConflict {
+		t.Errorf("expected 409 for an open blocker on a done task, got %d", code)
+	}
+	if code := do(`{"blocked_by":1,"extra":true}`, "application/json"); code != http.StatusBadRequest {
+		t.Errorf("expected 400 for unknown field, got %d", code)
+	}
+	if code := do(`{"blocked_by":1}`, "text/plain"); code != http.StatusBadRequest {
+		t.Errorf("expected 400 for wrong Content-Type, got %d", code)
+	}
+	if task, _ := store.Get(2); len(task.BlockedBy) != 0 {
+		t.Errorf("expected no dependency to be added, got %v", task.BlockedBy)
+	}
+}
+
+func TestImportTasks_LimitsAndTitleRoundTrip(t *testing.T) {
+	store := storage.NewMemoryStore()
+	h := NewHandlers(store)
+
+	body := strings.Repeat("Task line\n", maxImportSize/10+1)
+	req := httptest.NewRequest(http.MethodPost, "/tasks/import", strings.NewReader(body))
+	w := httptest.NewRecorder()
+	h.ImportTasks(w, req)
+	if w.Code != http.StatusRequestEntityTooLarge {
+		t.Errorf("expected 413 for oversized import, got %d", w.Code)
+	}
+
+	var tags []string
+	for i := range maxTags + 1 {
+		tags = append(tags, fmt.Sprintf("+p%d", i))
+	}
+	req = httptest.NewRequest(http.MethodPost, "/tasks/import", strings.NewReader("Too many "+strings.Join(tags, " ")+"\n"))
+	w = httptest.NewRecorder()
+	h.ImportTasks(w, req)
+	if w.Code != http.StatusUnprocessableEntity {
+		t.Errorf("expected 422 for too many projects, got %d", w.Code)
+	}
+
+	// слова, похожие на метки, остаются в заголовке после экспорта и импорта
+	title := "Ping @alice at 10:30 re:invoice"
+	req = httptest.NewRequest(http.MethodPost, "/tasks", bytes.NewBufferString(`{"title":"`+title+`"}`))
+	req.Header.Set("Content-Type", "application/json")
+	w = httptest.NewRecorder()
+	h.CreateTask(w, req)
+	if w.Code != http.StatusCreated {
+		t.Fatalf("expected 201 for title %q, got %d", title, w.Code)
+	}
+
+	req = httptest.NewRequest(http.MethodGet, "/tasks/export?format=todotxt", nil)
+	w = httptest.NewRecorder()
+	h.ExportTasks(w, req)
+
+	imported := storage.NewMemoryStore()
+	req = httptest.NewRequest(http.MethodPost, "/tasks/import", bytes.NewReader(w.Body.Bytes()))
+	w = httptest.NewRecorder()
+	NewHandlers(imported).ImportTasks(w, req)
+	task, _ := imported.Get(1)
+	if w.Code != http.StatusOK || task == nil || task.Title != title || len(task.Contexts) != 0 || task.Extensions != nil {
+		t.Errorf("expected title to round-trip, got %d %+v", w.Code, task)
+	}
+}
+
+func TestHandlers_RejectOversizedBodies(t *testing.T) {
+	store := storage.NewMemoryStore()
+	store.Create("Existing")
+	h := NewHandlers(store)
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /tasks", h.CreateTask)
+	mux.HandleFunc("POST /tasks:batch", h.BatchTasks)
+	mux.HandleFunc("PATCH /tasks/", h.UpdateTask)
+	mux.HandleFunc("POST /tasks/{id}/dependencies", h.AddDependency)
+
+	padding := strings.Repeat(" ", maxBodySize)
+	for _, tc := range []struct{ method, path, body string }{
+		{http.MethodPost, "/tasks", `{"title":"Buy milk"}`},
+		{http.MethodPost, "/tasks:batch", `{"operations":[{"op":"create","task":{"title":"Buy milk"}}]}`},
+		{http.MethodPatch, "/tasks/1", `{"done":true}`},
+		{http.MethodPost, "/tasks/1/dependencies", `{"blocked_by":1}`},
+	} {
+		req := httptest.NewRequest(tc.method, tc.path, bytes.NewBufferString(tc.body+padding))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		mux.ServeHTTP(w, req)
+		if w.Code != http.StatusRequestEntityTooLarge {
+			t.Errorf("%s %s: expected 413, got %d", tc.method, tc.path, w.Code)
+		}
+	}
+	if task, _ := store.Get(1); task.Done || len(store.List()) != 1 {
+		t.Errorf("expected oversized requests to change nothing")
+	}
+}
@@ -0,0 +1,118 @@
+package api
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"net/http"
+	"slices"
+
+	"github.com/icestormerrr/pz3-http/internal/idempotency"
+)
+
+const maxIdempotencyKeyLength = 255
+
+// WithIdempotency повторяет сохранённый ответ для запросов с тем же заголовком
+// Idempotency-Key, чтобы повторная отправка POST не создавала дубликаты.
+// Ответы 5xx не сохраняются: такой запрос можно безопасно повторить.
+func WithIdempotency(store idempotency.Store, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		key := r.Header.Get("Idempotency-Key")
+		if key == "" {
+			next.ServeHTTP(w, r)
+			return
+		}
+		if len(key) > maxIdempotencyKeyLength {
+			BadRequest(w, "Idempotency-Key is too long")
+			return
+		}
+
+		// тело читается до резервирования ключа: слишком большой запрос не должен его занимать
+		body, ok := readBody(w, r, maxBodySize)
+		if !ok {
+			return
+		}
+		r.Body = io.NopCloser(bytes.NewReader(body))
+
+		// ключ действует в пределах метода и пути
+		scoped := r.Method + " " + r.URL.Path + " " + key
+		saved, err := store.Begin(scoped, fingerprint(r, body))
+		switch {
+		case errors.Is(err, idempotency.ErrMismatch):
+			Unprocessable(w, "Idempotency-Key was already used with a different request")
+			return
+		case errors.Is(err, idempotency.ErrInFlight):
+			Conflict(w, "request with this Idempotency-Key is still in progress")
+			return
+		case err != nil:
+			Internal(w, "idempotency store error")
+			return
+		case saved != nil:
+			for k, v := range saved.Header {
+				w.Header()[k] = v
+			}
+			w.Header().Set("Idempotent-Replayed", "true")
+			w.WriteHeader(saved.Status)
+			_, _ = w.Write(saved.Body)
+			return
+		}
+
+		// заголовки, выставленные до обработчика (например, CORS), зависят от запроса и не сохраняются
+		before := w.Header().Clone()
+		rec := &captureWriter{ResponseWriter: w, status: http.StatusOK}
+		completed := false
+		defer func() {
+			// паника или 5xx — ключ освобождается для повторной попытки
+			if !completed {
+				_ = store.Release(scoped)
+			}
+		}()
+
+		next.ServeHTTP(rec, r)
+
+		if rec.status < http.StatusInternalServerError {
+			_ = store.Complete(scoped, idempotency.Response{
+				Status: rec.status,
+				Header: headerDiff(before, rec.Header()),
+				Body:   rec.body.Bytes(),
+			})
+			completed = true
+		}
+	})
+}
+
+func headerDiff(before, after http.Header) http.Header {
+	diff := http.Header{}
+	for k, v := range after {
+		if !slices.Equal(before[k], v) {
+			diff[k] = slices.Clone(v)
+		}
+	}
+	return diff
+}
+
+func fingerprint(r *http.Request, body []byte) string {
+	h := sha256.New()
+	io.WriteString(h, r.Method+" "+r.URL.RequestURI()+"\n")
+	h.Write(body)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// captureWriter пишет ответ клиенту и одновременно запоминает его.
+type captureWriter struct {
+	http.ResponseWriter
+	status int
+	body   bytes.Buffer
+}
+
+func (c *captureWriter) WriteHeader(code int) {
+	c.status = code
+	c.ResponseWriter.WriteHeader(code)
+}
+
+func (c *captureWriter) Write(b []byte) (int, error) {
+	c.body.Write(b)
+	return c.ResponseWriter.Write(b)
+}
@@ -0,0 +1,65 @@
+package api
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/icestormerrr/pz3-http/internal/storage"
+)
+
+func taskETag(t *storage.Task) string {
+	return `"` + strconv.FormatInt(t.Version, 10) + `"`
+}
+
+// parseIfMatch разбирает If-Match в условие для хранилища. Слабые ETag (W/"...")
+// для If-Match не подходят (RFC 9110, сильное сравнение), поэтому они ничему не соответствуют.
+// Возвращает nil, если заголовка нет.
+func parseIfMatch(r *http.Request) *storage.VersionMatch {
+	return parseETagList(r.Header.Values("If-Match"))
+}
+
+func parseETagList(values []string) *storage.VersionMatch {
+	if len(values) == 0 {
+		return nil
+	}
+	match := &storage.VersionMatch{Versions: []int64{}}
+	for _, value := range values {
+		for _, tag := range strings.Split(value, ",") {
+			tag = strings.TrimSpace(tag)
+			if tag == "*" {
+				match.Any = true
+				continue
+			}
+			if len(tag) < 2 || tag[0] != '"' || tag[len(tag)-1] != '"' {
+				continue
+			}
+			if v, err := strconv.ParseInt(tag[1:len(tag)-1], 10, 64); err == nil {
+				match.Versions = append(match.Versions, v)
+			}
+		}
+	}
+	return match
+}
+
+// ifNoneMatch сообщает, совпал ли If-None-Match с текущей версией (для ответа 304).
+func ifNoneMatch(r *http.Request, etag string) bool {
+	for _, value := range r.Header.Values("If-None-Match") {
+		for _, tag := range strings.Split(value, ",") {
+			tag = strings.TrimPrefix(strings.TrimSpace(tag), "W/")
+			if tag == "*" || tag == etag {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// requirePrecondition в строгом режиме отвечает 428, если клиент не прислал If-Match.
+func (h *Handlers) requirePrecondition(w http.ResponseWriter, match *storage.VersionMatch) bool {
+	if h.RequireIfMatch && match == nil {
+		PreconditionRequired(w, "If-Match header is required")
+		return false
+	}
+	return true
+}
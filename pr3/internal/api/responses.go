@@ -23,6 +23,10 @@ func NotFound(w http.ResponseWriter, msg string) {
 	JSON(w, http.StatusNotFound, ErrorResponse{Error: msg})
 }
 
+func Conflict(w http.ResponseWriter, msg string) {
+	JSON(w, http.StatusConflict, ErrorResponse{Error: msg})
+}
+
 func Internal(w http.ResponseWriter, msg string) {
 	JSON(w, http.StatusInternalServerError, ErrorResponse{Error: msg})
 }
@@ -30,3 +34,19 @@ func Internal(w http.ResponseWriter, msg string) {
 func Unprocessable(w http.ResponseWriter, msg string) {
 	JSON(w, http.StatusUnprocessableEntity, ErrorResponse{Error: msg})
 }
+
+func UnsupportedMediaType(w http.ResponseWriter, msg string) {
+	JSON(w, http.StatusUnsupportedMediaType, ErrorResponse{Error: msg})
+}
+
+func PreconditionFailed(w http.ResponseWriter, msg string) {
+	JSON(w, http.StatusPreconditionFailed, ErrorResponse{Error: msg})
+}
+
+func PreconditionRequired(w http.ResponseWriter, msg string) {
+	JSON(w, http.StatusPreconditionRequired, ErrorResponse{Error: msg})
+}
+
+func PayloadTooLarge(w http.ResponseWriter, msg string) {
+	JSON(w, http.StatusRequestEntityTooLarge, ErrorResponse{Error: msg})
+}
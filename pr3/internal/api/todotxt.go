@@ -0,0 +1,218 @@
+package api
+
+import (
+	"bytes"
+	"errors"
+	"maps"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/icestormerrr/pz3-http/internal/storage"
+	"github.com/icestormerrr/pz3-http/internal/todotxt"
+)
+
+const maxImportSize = 5 << 20
+
+type importResponse struct {
+	Imported int                 `json:"imported"`
+	Tasks    []*storage.Task     `json:"tasks"`
+	Errors   []todotxt.LineError `json:"errors"`
+}
+
+// POST /tasks/import — импорт из todo.txt. Как и в /tasks:batch, по умолчанию импорт
+// атомарный: при ошибке в любой строке ничего не создаётся. С ?atomic=false
+// создаются все корректные строки, а ошибки возвращаются с номерами строк.
+func (h *Handlers) ImportTasks(w http.ResponseWriter, r *http.Request) {
+	atomic := true
+	if v := r.URL.Query().Get("atomic"); v != "" {
+		var err error
+		if atomic, err = strconv.ParseBool(v); err != nil {
+			BadRequest(w, "atomic must be true or false")
+			return
+		}
+	}
+
+	body, ok := readBody(w, r, maxImportSize)
+	if !ok {
+		return
+	}
+	items, lineErrs, err := todotxt.Parse(bytes.NewReader(body))
+	if err != nil {
+		BadRequest(w, "failed to read body: "+err.Error())
+		return
+	}
+
+	ops := make([]storage.BatchOp, 0, len(items))
+	for _, it := range items {
+		in, err := todoItemToTask(it)
+		if err != nil {
+			lineErrs = append(lineErrs, todotxt.LineError{Line: it.Line, Err: err.Error()})
+			continue
+		}
+		ops = append(ops, storage.BatchOp{Kind: storage.BatchCreate, Create: in})
+	}
+	slices.SortFunc(lineErrs, func(a, b todotxt.LineError) int { return a.Line - b.Line })
+
+	resp := importResponse{Tasks: []*storage.Task{}, Errors: lineErrs}
+	if resp.Errors == nil {
+		resp.Errors = []todotxt.LineError{}
+	}
+	if atomic && len(lineErrs) > 0 {
+		JSON(w, http.StatusUnprocessableEntity, resp)
+		return
+	}
+	if len(ops) == 0 {
+		JSON(w, http.StatusOK, resp)
+		return
+	}
+
+	results, err := h.Store.Batch(ops, true)
+	if err != nil {
+		Internal(w, "failed to import tasks")
+		return
+	}
+	for _, res := range results {
+		resp.Tasks = append(resp.Tasks, res.Task)
+	}
+	resp.Imported = len(resp.Tasks)
+	JSON(w, http.StatusOK, resp)
+}
+
+// GET /tasks/export?format=todotxt — фильтры q, done и ready как у списка задач.
+// Описание задачи в todo.txt не переносится: в формате есть только одна строка текста.
+func (h *Handlers) ExportTasks(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	if f := query.Get("format"); f != "" && f != "todotxt" {
+		BadRequest(w, "format must be todotxt")
+		return
+	}
+
+	q := storage.ListQuery{Query: strings.TrimSpace(query.Get("q"))}
+	for name, dst := range map[string]**bool{"done": &q.Done, "ready": &q.Ready} {
+		if v := query.Get(name); v != "" {
+			b, err := strconv.ParseBool(v)
+			if err != nil {
+				BadRequest(w, name+" must be true or false")
+				return
+			}
+			*dst = &b
+		}
+	}
+
+	res, err := h.Store.Query(q)
+	if err != nil {
+		Internal(w, "failed to list tasks")
+		return
+	}
+
+	items := make([]todotxt.Item, len(res.Tasks))
+	for i, t := range res.Tasks {
+		items[i] = taskToTodoItem(t)
+	}
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	w.Header().Set("Content-Disposition", `attachment; filename="todo.txt"`)
+	_ = todotxt.Write(w, items)
+}
+
+// Приоритеты todo.txt (A)–(Z) сводятся к трём уровням: A — high, B — normal, остальные — low.
+func todoPriority(letter byte) storage.Priority {
+	switch {
+	case letter == 0:
+		return storage.PriorityNone
+	case letter == 'A':
+		return storage.PriorityHigh
+	case letter == 'B':
+		return storage.PriorityNormal
+	}
+	return storage.PriorityLow
+}
+
+func todoLetter(p storage.Priority) byte {
+	switch p {
+	case storage.PriorityHigh:
+		return 'A'
+	case storage.PriorityNormal:
+		return 'B'
+	case storage.PriorityLow:
+		return 'C'
+	}
+	return 0
+}
+
+func todoItemToTask(it todotxt.Item) (storage.NewTask, error) {
+	in := storage.NewTask{
+		Done:        it.Done,
+		CreatedAt:   it.Created,
+		CompletedAt: it.Completed,
+		Priority:    todoPriority(it.Priority),
+	}
+	var err error
+	if in.Title, err = validateTitle(it.Text); err != nil {
+		return in, err
+	}
+	if len(it.Projects) > 0 {
+		if in.Projects, err = validateTags("projects", it.Projects); err != nil {
+			return in, err
+		}
+	}
+	if len(it.Contexts) > 0 {
+		if in.Contexts, err = validateTags("contexts", it.Contexts); err != nil {
+			return in, err
+		}
+	}
+
+	ext := maps.Clone(it.Extensions)
+	if due, ok := ext["due"]; ok {
+		t, err := time.Parse(time.DateOnly, due)
+		if err != nil {
+			return in, errors.New("due must be YYYY-MM-DD")
+		}
+		in.DueDate = &t
+		delete(ext, "due")
+	}
+	// у выполненных задач приоритет по соглашению хранится в pri:X
+	if pri, ok := ext["pri"]; ok {
+		if len(pri) != 1 || pri[0] < 'A' || pri[0] > 'Z' {
+			return in, errors.New("pri must be a letter A-Z")
+		}
+		in.Priority = todoPriority(pri[0])
+		delete(ext, "pri")
+	}
+	if len(ext) > 0 {
+		if in.Extensions, err = validateExtensions(ext); err != nil {
+			return in, err
+		}
+	}
+	return in, nil
+}
+
+func taskToTodoItem(t *storage.Task) todotxt.Item {
+	created := t.CreatedAt.UTC()
+	it := todotxt.Item{
+		Done:       t.Done,
+		Created:    &created,
+		Completed:  t.CompletedAt,
+		Text:       t.Title,
+		Projects:   t.Projects,
+		Contexts:   t.Contexts,
+		Extensions: maps.Clone(t.Extensions),
+	}
+	letter := todoLetter(t.Priority)
+	if t.DueDate != nil || (t.Done && letter != 0) {
+		if it.Extensions == nil {
+			it.Extensions = map[string]string{}
+		}
+	}
+	if t.DueDate != nil {
+		it.Extensions["due"] = t.DueDate.UTC().Format(time.DateOnly)
+	}
+	if t.Done && letter != 0 {
+		it.Extensions["pri"] = string(letter)
+	} else {
+		it.Priority = letter
+	}
+	return it
+}
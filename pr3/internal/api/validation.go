@@ -0,0 +1,291 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/icestormerrr/pz3-http/internal/storage"
+)
+
+const maxDescriptionLength = 2000
+
+// validationError несёт HTTP-статус, чтобы одни и те же проверки можно было
+// использовать и в одиночных обработчиках, и в пакетных операциях.
+type validationError struct {
+	Status int
+	Msg    string
+}
+
+func (e *validationError) Error() string { return e.Msg }
+
+func invalid(msg string) error {
+	return &validationError{Status: http.StatusBadRequest, Msg: msg}
+}
+
+func unprocessable(msg string) error {
+	return &validationError{Status: http.StatusUnprocessableEntity, Msg: msg}
+}
+
+func writeValidationError(w http.ResponseWriter, err error) {
+	if ve, ok := err.(*validationError); ok {
+		JSON(w, ve.Status, ErrorResponse{Error: ve.Msg})
+		return
+	}
+	BadRequest(w, err.Error())
+}
+
+// validateTitle нормализует заголовок: пустой — 400, короче 3 или длиннее 140 символов — 422.
+func validateTitle(title string) (string, error) {
+	title = strings.TrimSpace(title)
+	if title == "" {
+		return "", invalid("title is required")
+	}
+
+	n := utf8.RuneCountInString(title)
+	if n < 3 {
+		return "", unprocessable("title is too short")
+	}
+
+	if n > 140 {
+		return "", unprocessable("title is too long")
+	}
+	return title, nil
+}
+
+func validateDescription(description string) (string, error) {
+	description = strings.TrimSpace(description)
+	if utf8.RuneCountInString(description) > maxDescriptionLength {
+		return "", unprocessable(fmt.Sprintf("description is too long (max %d characters)", maxDescriptionLength))
+	}
+	return description, nil
+}
+
+func validatePriority(priority string) (storage.Priority, error) {
+	p := storage.Priority(strings.ToLower(strings.TrimSpace(priority)))
+	if !p.Valid() {
+		return "", unprocessable("priority must be one of low, normal, high")
+	}
+	return p, nil
+}
+
+// parseDueDate принимает RFC 3339 или просто дату YYYY-MM-DD (полночь UTC).
+func parseDueDate(value string) (*time.Time, error) {
+	value = strings.TrimSpace(value)
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		t = t.UTC()
+		return &t, nil
+	}
+	if t, err := time.Parse(time.DateOnly, value); err == nil {
+		return &t, nil
+	}
+	return nil, unprocessable("due_date must be RFC 3339 timestamp or YYYY-MM-DD date")
+}
+
+const maxTags = 20
+
+// validateTags проверяет проекты и контексты: непустые слова без пробелов, без повторов.
+func validateTags(field string, tags []string) ([]string, error) {
+	if len(tags) > maxTags {
+		return nil, unprocessable(fmt.Sprintf("%s: too many values (max %d)", field, maxTags))
+	}
+	out := make([]string, 0, len(tags))
+	for _, tag := range tags {
+		tag = strings.TrimLeft(strings.TrimSpace(tag), "+@")
+		if tag == "" || strings.ContainsFunc(tag, unicode.IsSpace) {
+			return nil, unprocessable(field + " must be non-empty words without spaces")
+		}
+		if !slices.Contains(out, tag) {
+			out = append(out, tag)
+		}
+	}
+	return out, nil
+}
+
+// validateExtensions проверяет пары key:value из todo.txt. Ключи due и pri заняты
+// полями due_date и priority.
+func validateExtensions(ext map[string]string) (map[string]string, error) {
+	if len(ext) > maxTags {
+		return nil, unprocessable(fmt.Sprintf("extensions: too many values (max %d)", maxTags))
+	}
+	for k, v := range ext {
+		if k == "" || v == "" || strings.ContainsAny(k+v, ": \t\n") {
+			return nil, unprocessable("extensions must be non-empty keys and values without spaces or colons")
+		}
+		if k == "due" || k == "pri" {
+			return nil, unprocessable("extension " + k + " is reserved, use due_date or priority")
+		}
+	}
+	return ext, nil
+}
+
+type createTaskRequest struct {
+	Title       string            `json:"title"`
+	Description string            `json:"description"`
+	Priority    string            `json:"priority"`
+	DueDate     *string           `json:"due_date"`
+	Projects    []string          `json:"projects"`
+	Contexts    []string          `json:"contexts"`
+	Extensions  map[string]string `json:"extensions"`
+}
+
+func (req createTaskRequest) toNewTask() (storage.NewTask, error) {
+	var in storage.NewTask
+	var err error
+	if in.Title, err = validateTitle(req.Title); err != nil {
+		return in, err
+	}
+	if in.Description, err = validateDescription(req.Description); err != nil {
+		return in, err
+	}
+	if in.Priority, err = validatePriority(req.Priority); err != nil {
+		return in, err
+	}
+	if req.DueDate != nil {
+		if in.DueDate, err = parseDueDate(*req.DueDate); err != nil {
+			return in, err
+		}
+	}
+	if len(req.Projects) > 0 {
+		if in.Projects, err = validateTags("projects", req.Projects); err != nil {
+			return in, err
+		}
+	}
+	if len(req.Contexts) > 0 {
+		if in.Contexts, err = validateTags("contexts", req.Contexts); err != nil {
+			return in, err
+		}
+	}
+	if len(req.Extensions) > 0 {
+		if in.Extensions, err = validateExtensions(req.Extensions); err != nil {
+			return in, err
+		}
+	}
+	return in, nil
+}
+
+// parseMergePatch разбирает тело по RFC 7396: меняются только присланные поля,
+// null сбрасывает необязательное поле.
+func parseMergePatch(body []byte) (storage.TaskUpdatePayload, error) {
+	var payload storage.TaskUpdatePayload
+
+	var fields map[string]json.RawMessage
+	dec := json.NewDecoder(bytes.NewReader(body))
+	if err := dec.Decode(&fields); err != nil || fields == nil {
+		return payload, invalid("merge patch must be a JSON object")
+	}
+
+	for name, raw := range fields {
+		isNull := bytes.Equal(bytes.TrimSpace(raw), []byte("null"))
+		switch name {
+		case "title":
+			var v string
+			if isNull || json.Unmarshal(raw, &v) != nil {
+				return payload, unprocessable("title must be a string")
+			}
+			title, err := validateTitle(v)
+			if err != nil {
+				return payload, err
+			}
+			payload.Title = &title
+
+		case "description":
+			var v string
+			if !isNull && json.Unmarshal(raw, &v) != nil {
+				return payload, unprocessable("description must be a string or null")
+			}
+			description, err := validateDescription(v)
+			if err != nil {
+				return payload, err
+			}
+			payload.Description = &description
+
+		case "priority":
+			var v string
+			if !isNull && json.Unmarshal(raw, &v) != nil {
+				return payload, unprocessable("priority must be a string or null")
+			}
+			priority, err := validatePriority(v)
+			if err != nil {
+				return payload, err
+			}
+			payload.Priority = &priority
+
+		case "done":
+			var v bool
+			if !isNull && json.Unmarshal(raw, &v) != nil {
+				return payload, unprocessable("done must be a boolean or null")
+			}
+			payload.Done = &v
+
+		case "due_date":
+			if isNull {
+				payload.ClearDueDate = true
+				continue
+			}
+			var v string
+			if json.Unmarshal(raw, &v) != nil {
+				return payload, unprocessable("due_date must be a string or null")
+			}
+			due, err := parseDueDate(v)
+			if err != nil {
+				return payload, err
+			}
+			payload.DueDate = due
+
+		case "projects", "contexts":
+			var v []string
+			if !isNull && json.Unmarshal(raw, &v) != nil {
+				return payload, unprocessable(name + " must be an array of strings or null")
+			}
+			tags, err := validateTags(name, v)
+			if err != nil {
+				return payload, err
+			}
+			if name == "projects" {
+				payload.Projects = &tags
+			} else {
+				payload.Contexts = &tags
+			}
+
+		case "extensions":
+			// вложенный объект сливается по RFC 7396: null удаляет ключ, null вместо объекта — все ключи
+			if isNull {
+				payload.ClearExtensions = true
+				continue
+			}
+			var v map[string]*string
+			if json.Unmarshal(raw, &v) != nil {
+				return payload, unprocessable("extensions must be an object with string or null values")
+			}
+			set := map[string]string{}
+			for k, val := range v {
+				if val != nil {
+					set[k] = *val
+				} else if k == "" {
+					return payload, unprocessable("extensions must be non-empty keys and values without spaces or colons")
+				}
+			}
+			if _, err := validateExtensions(set); err != nil {
+				return payload, err
+			}
+			payload.Extensions = v
+
+		case "id", "created_at", "updated_at", "completed_at", "version":
+			return payload, unprocessable(name + " is read-only")
+
+		case "blocked_by":
+			return payload, unprocessable("blocked_by is changed via /tasks/{id}/dependencies")
+
+		default:
+			return payload, invalid("unknown field: " + name)
+		}
+	}
+	return payload, nil
+}
@@ -0,0 +1,91 @@
+package idempotency
+
+import (
+	"container/list"
+	"sync"
+	"time"
+)
+
+// MemoryStore — Store в памяти процесса. Записи живут ttl с момента резервирования
+// ключа, просроченные удаляются при обращениях. Хранится не больше maxKeys ключей:
+// при переполнении вытесняются самые старые, чтобы поток уникальных ключей
+// не раздувал память на весь ttl.
+type MemoryStore struct {
+	mu      sync.Mutex
+	ttl     time.Duration
+	maxKeys int
+	entries map[string]*list.Element
+	// order — ключи в порядке резервирования; ttl общий, поэтому это и порядок истечения
+	order *list.List
+}
+
+type memoryEntry struct {
+	key string
+	entry
+}
+
+// NewMemoryStore создаёт хранилище; maxKeys <= 0 снимает ограничение на число ключей.
+func NewMemoryStore(ttl time.Duration, maxKeys int) *MemoryStore {
+	return &MemoryStore{
+		ttl:     ttl,
+		maxKeys: maxKeys,
+		entries: make(map[string]*list.Element),
+		order:   list.New(),
+	}
+}
+
+func (s *MemoryStore) Begin(key, fingerprint string) (*Response, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	s.sweepLocked(now)
+
+	if el, ok := s.entries[key]; ok {
+		e := el.Value.(*memoryEntry)
+		if e.fingerprint != fingerprint {
+			return nil, ErrMismatch
+		}
+		if e.resp == nil {
+			return nil, ErrInFlight
+		}
+		return e.resp, nil
+	}
+
+	if s.maxKeys > 0 && s.order.Len() >= s.maxKeys {
+		s.removeLocked(s.order.Front())
+	}
+	e := &memoryEntry{key: key, entry: entry{fingerprint: fingerprint, expires: now.Add(s.ttl)}}
+	s.entries[key] = s.order.PushBack(e)
+	return nil, nil
+}
+
+func (s *MemoryStore) Complete(key string, resp Response) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if el, ok := s.entries[key]; ok {
+		el.Value.(*memoryEntry).resp = &resp
+	}
+	return nil
+}
+
+func (s *MemoryStore) Release(key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if el, ok := s.entries[key]; ok && el.Value.(*memoryEntry).resp == nil {
+		s.removeLocked(el)
+	}
+	return nil
+}
+
+// sweepLocked удаляет просроченные записи с начала очереди.
+func (s *MemoryStore) sweepLocked(now time.Time) {
+	for el := s.order.Front(); el != nil && !now.Before(el.Value.(*memoryEntry).expires); el = s.order.Front() {
+		s.removeLocked(el)
+	}
+}
+
+func (s *MemoryStore) removeLocked(el *list.Element) {
+	s.order.Remove(el)
+	delete(s.entries, el.Value.(*memoryEntry).key)
+}
@@ -0,0 +1,57 @@
+package idempotency
+
+import (
+	"errors"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestMemoryStore_ReplayAndMismatch(t *testing.T) {
+	s := NewMemoryStore(time.Hour, 0)
+	if resp, err := s.Begin("k", "a"); resp != nil || err != nil {
+		t.Fatalf("expected fresh key, got %v, %v", resp, err)
+	}
+	if _, err := s.Begin("k", "a"); !errors.Is(err, ErrInFlight) {
+		t.Errorf("expected ErrInFlight, got %v", err)
+	}
+	s.Complete("k", Response{Status: 201})
+	if resp, err := s.Begin("k", "a"); err != nil || resp == nil || resp.Status != 201 {
+		t.Errorf("expected saved response, got %v, %v", resp, err)
+	}
+	if _, err := s.Begin("k", "b"); !errors.Is(err, ErrMismatch) {
+		t.Errorf("expected ErrMismatch, got %v", err)
+	}
+}
+
+func TestMemoryStore_EvictsOldestKeys(t *testing.T) {
+	s := NewMemoryStore(time.Hour, 3)
+	for i := range 5 {
+		key := strconv.Itoa(i)
+		s.Begin(key, "fp")
+		s.Complete(key, Response{Status: 201})
+	}
+	if n := len(s.entries); n != 3 {
+		t.Fatalf("expected 3 keys, got %d", n)
+	}
+	// ключи 0 и 1 вытеснены и резервируются заново
+	if resp, _ := s.Begin("0", "fp"); resp != nil {
+		t.Errorf("expected evicted key to start over, got %v", resp)
+	}
+	if resp, _ := s.Begin("4", "fp"); resp == nil {
+		t.Errorf("expected the newest key to be kept")
+	}
+}
+
+func TestMemoryStore_ExpiresAndReleases(t *testing.T) {
+	s := NewMemoryStore(time.Millisecond, 0)
+	s.Begin("old", "fp")
+	time.Sleep(5 * time.Millisecond)
+	if _, err := s.Begin("old", "other"); err != nil {
+		t.Errorf("expected expired key to be reusable, got %v", err)
+	}
+	s.Release("old")
+	if _, ok := s.entries["old"]; ok || s.order.Len() != 0 {
+		t.Errorf("expected released key to be removed")
+	}
+}
@@ -0,0 +1,39 @@
+package idempotency
+
+import (
+	"errors"
+	"net/http"
+	"time"
+)
+
+var (
+	// ErrInFlight — запрос с этим ключом ещё обрабатывается.
+	ErrInFlight = errors.New("request with this idempotency key is in progress")
+	// ErrMismatch — ключ уже использован для запроса с другим телом.
+	ErrMismatch = errors.New("idempotency key was used with a different request")
+)
+
+// Response — сохранённый ответ, который повторяется для запросов с тем же ключом.
+type Response struct {
+	Status int
+	Header http.Header
+	Body   []byte
+}
+
+// Store хранит ответы по ключам идемпотентности.
+//
+// Begin резервирует ключ за запросом с данным отпечатком. Если ответ уже сохранён,
+// он возвращается для повтора; если ключ занят незавершённым запросом — ErrInFlight;
+// если отпечаток отличается — ErrMismatch. После обработки вызывающий обязан
+// вызвать Complete, чтобы сохранить ответ, или Release, чтобы освободить ключ.
+type Store interface {
+	Begin(key, fingerprint string) (*Response, error)
+	Complete(key string, resp Response) error
+	Release(key string) error
+}
+
+type entry struct {
+	fingerprint string
+	resp        *Response
+	expires     time.Time
+}
@@ -0,0 +1,107 @@
+package storage
+
+import "errors"
+
+type BatchOpKind string
+
+const (
+	BatchCreate BatchOpKind = "create"
+	BatchUpdate BatchOpKind = "update"
+	BatchDelete BatchOpKind = "delete"
+)
+
+// ErrBatchAborted получают операции атомарного пакета, которые не были применены
+// из-за ошибки в другой операции.
+var ErrBatchAborted = errors.New("batch aborted")
+
+type BatchOp struct {
+	Kind   BatchOpKind
+	ID     int64
+	Create NewTask
+	Update TaskUpdatePayload
+	Match  *VersionMatch
+}
+
+type BatchResult struct {
+	Task *Task
+	Err  error
+}
+
+// Batch применяет операции по порядку. В атомарном режиме все операции проверяются
+// и применяются под одной блокировкой и попадают в журнал одной записью: при любой
+// ошибке хранилище не меняется, а результат с ошибкой возвращается вместе с ErrBatchAborted.
+// Без atomic каждая операция применяется независимо.
+func (s *MemoryStore) Batch(ops []BatchOp, atomic bool) ([]BatchResult, error) {
+	if !atomic {
+		results := make([]BatchResult, len(ops))
+		for i, op := range ops {
+			results[i] = s.applyOp(op)
+		}
+		return results, nil
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	x := s.begin()
+	results := make([]BatchResult, len(ops))
+	failed := -1
+
+	for i, op := range ops {
+		switch op.Kind {
+		case BatchCreate:
+			results[i].Task = x.create(op.Create)
+		case BatchUpdate:
+			results[i].Task, results[i].Err = x.update(op.ID, op.Match, op.Update)
+		case BatchDelete:
+			if err := x.delete(op.ID, op.Match); err != nil && !errors.Is(err, ErrNotFound) {
+				results[i].Err = err
+			}
+		default:
+			results[i].Err = errors.New("unknown operation")
+		}
+
+		if results[i].Err != nil {
+			failed = i
+			break
+		}
+	}
+
+	if failed >= 0 {
+		for i := range results {
+			results[i].Task = nil
+			if i != failed {
+				results[i].Err = ErrBatchAborted
+			}
+		}
+		return results, ErrBatchAborted
+	}
+
+	if err := x.commit(); err != nil {
+		return nil, err
+	}
+	for i := range results {
+		if results[i].Task != nil {
+			results[i].Task = results[i].Task.clone()
+		}
+	}
+	return results, nil
+}
+
+func (s *MemoryStore) applyOp(op BatchOp) BatchResult {
+	var res BatchResult
+	switch op.Kind {
+	case BatchCreate:
+		res.Task, res.Err = s.CreateTask(op.Create)
+	case BatchUpdate:
+		res.Task, res.Err = s.UpdateIf(op.ID, op.Match, op.Update)
+	case BatchDelete:
+		res.Err = s.DeleteIf(op.ID, op.Match)
+		if op.Match == nil && errors.Is(res.Err, ErrNotFound) {
+			res.Err = nil
+		}
+	default:
+		res.Err = errors.New("unknown operation")
+	}
+	return res
+}
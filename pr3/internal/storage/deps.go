@@ -0,0 +1,185 @@
+package storage
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+)
+
+// MaxBlockers ограничивает число блокеров у одной задачи; на него же
+// опирается оценка сложности GraphQL-запросов с blockedBy.
+const MaxBlockers = 20
+
+var (
+	ErrCycle           = errors.New("dependency would create a cycle")
+	ErrBlockerNotFound = errors.New("blocker not found")
+	ErrTooManyBlockers = fmt.Errorf("task cannot have more than %d blockers", MaxBlockers)
+)
+
+// BlockedError — задачу нельзя завершить, пока открыты задачи из Blockers.
+type BlockedError struct {
+	Blockers []int64
+}
+
+func (e *BlockedError) Error() string {
+	return fmt.Sprintf("task has open blockers: %v", e.Blockers)
+}
+
+// DoneDependentsError — задачу нельзя открыть заново, пока от неё зависят выполненные
+// задачи Dependents: они оказались бы завершены при открытом блокере.
+type DoneDependentsError struct {
+	Dependents []int64
+}
+
+func (e *DoneDependentsError) Error() string {
+	return fmt.Sprintf("task blocks completed tasks: %v", e.Dependents)
+}
+
+func (x *tx) openBlockers(t *Task) []int64 {
+	var open []int64
+	for _, id := range t.BlockedBy {
+		if b, ok := x.get(id); ok && !b.Done {
+			open = append(open, id)
+		}
+	}
+	return open
+}
+
+func (x *tx) doneDependents(id int64) []int64 {
+	var done []int64
+	x.each(func(d *Task) {
+		if d.Done && slices.Contains(d.BlockedBy, id) {
+			done = append(done, d.ID)
+		}
+	})
+	slices.Sort(done)
+	return done
+}
+
+// AddDependency помечает задачу id заблокированной задачей blocker.
+// Связь, образующая цикл (в том числе задача сама на себя), отклоняется с ErrCycle.
+func (s *MemoryStore) AddDependency(id, blocker int64, match *VersionMatch) (*Task, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	x := s.begin()
+	t, ok := x.get(id)
+	if err := match.check(t, ok); err != nil {
+		return nil, err
+	}
+	if _, ok := x.get(blocker); !ok {
+		return nil, ErrBlockerNotFound
+	}
+	if slices.Contains(t.BlockedBy, blocker) {
+		return t.clone(), nil
+	}
+	if len(t.BlockedBy) >= MaxBlockers {
+		return nil, ErrTooManyBlockers
+	}
+	// цикл появится, если blocker уже (транзитивно) ждёт задачу id
+	if blocker == id || s.reachableLocked(blocker, id) {
+		return nil, ErrCycle
+	}
+	// завершённая задача не может ждать открытую
+	if b, _ := x.get(blocker); t.Done && !b.Done {
+		return nil, &BlockedError{Blockers: []int64{blocker}}
+	}
+
+	updated := *t
+	updated.BlockedBy = append(slices.Clone(t.BlockedBy), blocker)
+	slices.Sort(updated.BlockedBy)
+	x.put(&updated)
+	if err := x.commit(); err != nil {
+		return nil, err
+	}
+	return updated.clone(), nil
+}
+
+func (s *MemoryStore) RemoveDependency(id, blocker int64, match *VersionMatch) (*Task, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	x := s.begin()
+	t, ok := x.get(id)
+	if err := match.check(t, ok); err != nil {
+		return nil, err
+	}
+	if !slices.Contains(t.BlockedBy, blocker) {
+		return t.clone(), nil
+	}
+
+	updated := *t
+	updated.BlockedBy = slices.DeleteFunc(slices.Clone(t.BlockedBy), func(b int64) bool { return b == blocker })
+	x.put(&updated)
+	if err := x.commit(); err != nil {
+		return nil, err
+	}
+	return updated.clone(), nil
+}
+
+// reachableLocked проверяет, ведёт ли цепочка blocked_by от from к target.
+func (s *MemoryStore) reachableLocked(from, target int64) bool {
+	seen := map[int64]bool{}
+	stack := []int64{from}
+	for len(stack) > 0 {
+		id := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		if id == target {
+			return true
+		}
+		if seen[id] {
+			continue
+		}
+		seen[id] = true
+		if t, ok := s.tasks[id]; ok {
+			stack = append(stack, t.BlockedBy...)
+		}
+	}
+	return false
+}
+
+// GraphNode — задача и (транзитивно) задачи, которые её блокируют.
+type GraphNode struct {
+	ID        int64        `json:"id"`
+	Title     string       `json:"title"`
+	Done      bool         `json:"done"`
+	BlockedBy []*GraphNode `json:"blocked_by"`
+}
+
+// Graph возвращает дерево зависимостей задачи. Граф ацикличен, но общая
+// зависимость нескольких задач попадает в дерево в каждой из веток.
+func (s *MemoryStore) Graph(id int64) (*GraphNode, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if _, ok := s.tasks[id]; !ok {
+		return nil, ErrNotFound
+	}
+	built := map[int64]*GraphNode{}
+	var build func(id int64) *GraphNode
+	build = func(id int64) *GraphNode {
+		if n, ok := built[id]; ok {
+			return n
+		}
+		t := s.tasks[id]
+		n := &GraphNode{ID: t.ID, Title: t.Title, Done: t.Done, BlockedBy: []*GraphNode{}}
+		built[id] = n
+		for _, b := range t.BlockedBy {
+			if _, ok := s.tasks[b]; ok {
+				n.BlockedBy = append(n.BlockedBy, build(b))
+			}
+		}
+		return n
+	}
+	return build(id), nil
+}
+
+// readyLocked — у задачи нет открытых блокеров.
+func (s *MemoryStore) readyLocked(t *Task) bool {
+	for _, id := range t.BlockedBy {
+		if b, ok := s.tasks[id]; ok && !b.Done {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,322 @@
+package storage
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+type DurableOptions struct {
+	Dir              string
+	SyncEvery        int
+	SyncInterval     time.Duration
+	SnapshotEvery    int
+	SnapshotInterval time.Duration
+}
+
+const (
+	walFileName      = "wal.log"
+	snapshotFileName = "snapshot.json"
+)
+
+type snapshotFile struct {
+	Auto  int64   `json:"auto"`
+	Tasks []*Task `json:"tasks"`
+}
+
+// walFile — то, что хранилищу нужно от файла журнала; в тестах подменяется для имитации сбоев.
+type walFile interface {
+	io.Writer
+	io.Seeker
+	Truncate(size int64) error
+	Sync() error
+	Close() error
+}
+
+type durability struct {
+	opts DurableOptions
+	wal  walFile
+	// size — длина журнала после последней успешной записи.
+	size int64
+	// failed — после неудачного fsync или отката записи журнал в неизвестном
+	// состоянии, и хранилище отказывается от дальнейших изменений.
+	failed error
+
+	unsynced      int
+	sinceSnapshot int
+
+	stop chan struct{}
+	done sync.WaitGroup
+}
+
+// OpenMemoryStore восстанавливает хранилище из снапшота и журнала в opts.Dir
+// и дальше пишет в журнал каждое изменение.
+func OpenMemoryStore(opts DurableOptions) (*MemoryStore, error) {
+	if opts.SyncEvery < 1 {
+		opts.SyncEvery = 1
+	}
+	if err := os.MkdirAll(opts.Dir, 0o755); err != nil {
+		return nil, fmt.Errorf("create data dir: %w", err)
+	}
+
+	s := NewMemoryStore()
+	if err := s.loadSnapshot(filepath.Join(opts.Dir, snapshotFileName)); err != nil {
+		return nil, err
+	}
+
+	walPath := filepath.Join(opts.Dir, walFileName)
+	f, err := os.OpenFile(walPath, os.O_RDWR|os.O_CREATE, 0o644)
+	if err != nil {
+		return nil, fmt.Errorf("open wal: %w", err)
+	}
+
+	offset, torn, err := replayWAL(f, s.tasks, &s.auto)
+	if err != nil {
+		f.Close()
+		return nil, fmt.Errorf("replay wal: %w", err)
+	}
+	if torn {
+		log.Printf("wal: torn record at offset %d, truncating", offset)
+		if err := f.Truncate(offset); err != nil {
+			f.Close()
+			return nil, fmt.Errorf("truncate wal: %w", err)
+		}
+		if err := f.Sync(); err != nil {
+			f.Close()
+			return nil, fmt.Errorf("sync wal: %w", err)
+		}
+	}
+	if _, err := f.Seek(offset, io.SeekStart); err != nil {
+		f.Close()
+		return nil, fmt.Errorf("seek wal: %w", err)
+	}
+
+	s.durable = &durability{opts: opts, wal: f, size: offset, stop: make(chan struct{})}
+	s.durable.done.Add(1)
+	go s.background()
+	return s, nil
+}
+
+func (s *MemoryStore) loadSnapshot(path string) error {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("read snapshot: %w", err)
+	}
+	var snap snapshotFile
+	if err := json.Unmarshal(data, &snap); err != nil {
+		return fmt.Errorf("parse snapshot: %w", err)
+	}
+	s.auto = snap.Auto
+	for _, t := range snap.Tasks {
+		if t.Version == 0 {
+			t.Version = 1
+		}
+		s.tasks[t.ID] = t
+		if t.ID > s.auto {
+			s.auto = t.ID
+		}
+	}
+	return nil
+}
+
+// appendWAL вызывается под s.mu.Lock. Если запись не удалась, журнал откатывается
+// к длине до неё: иначе изменение, о провале которого сообщили клиенту, вернулось бы
+// после перезапуска, а недописанные байты испортили бы следующие записи.
+func (s *MemoryStore) appendWAL(rec walRecord) error {
+	d := s.durable
+	if d == nil {
+		return nil
+	}
+	if d.failed != nil {
+		return fmt.Errorf("wal is unavailable: %w", d.failed)
+	}
+	buf, err := encodeWALRecord(rec)
+	if err != nil {
+		return fmt.Errorf("encode wal record: %w", err)
+	}
+	if _, err := d.wal.Write(buf); err != nil {
+		err = fmt.Errorf("write wal: %w", err)
+		s.rollbackWAL(err)
+		return err
+	}
+
+	d.unsynced++
+	d.sinceSnapshot++
+	if d.unsynced >= d.opts.SyncEvery {
+		if err := s.syncLocked(); err != nil {
+			// После неудачного fsync нельзя полагаться и на ранее записанные данные.
+			s.rollbackWAL(err)
+			d.failed = err
+			return err
+		}
+	}
+	d.size += int64(len(buf))
+	return nil
+}
+
+// rollbackWAL обрезает журнал до последней успешной записи. Если не вышло,
+// хранилище переходит в состояние отказа.
+func (s *MemoryStore) rollbackWAL(cause error) {
+	d := s.durable
+	err := d.wal.Truncate(d.size)
+	if err == nil {
+		_, err = d.wal.Seek(d.size, io.SeekStart)
+	}
+	if err != nil {
+		log.Printf("wal: rollback after %v failed: %v", cause, err)
+		d.failed = errors.Join(cause, err)
+	}
+}
+
+// maybeSnapshotLocked вызывается под s.mu.Lock уже после применения изменения в памяти,
+// иначе снапшот не содержал бы последнюю запись, а журнал с ней был бы обнулён.
+func (s *MemoryStore) maybeSnapshotLocked() {
+	d := s.durable
+	if d == nil || d.opts.SnapshotEvery <= 0 || d.sinceSnapshot < d.opts.SnapshotEvery {
+		return
+	}
+	if err := s.snapshotLocked(); err != nil {
+		log.Printf("wal: snapshot failed: %v", err)
+	}
+}
+
+func (s *MemoryStore) syncLocked() error {
+	d := s.durable
+	if d.unsynced == 0 {
+		return nil
+	}
+	if err := d.wal.Sync(); err != nil {
+		return fmt.Errorf("sync wal: %w", err)
+	}
+	d.unsynced = 0
+	return nil
+}
+
+// snapshotLocked пишет состояние целиком во временный файл, атомарно подменяет им снапшот
+// и обнуляет журнал. Если упасть между этими шагами, журнал просто применится повторно:
+// записи идемпотентны.
+func (s *MemoryStore) snapshotLocked() error {
+	d := s.durable
+	snap := snapshotFile{Auto: s.auto, Tasks: make([]*Task, 0, len(s.tasks))}
+	for _, t := range s.tasks {
+		snap.Tasks = append(snap.Tasks, t)
+	}
+	data, err := json.Marshal(snap)
+	if err != nil {
+		return err
+	}
+
+	path := filepath.Join(d.opts.Dir, snapshotFileName)
+	tmp := path + ".tmp"
+	f, err := os.OpenFile(tmp, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0o644)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Sync(); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		return err
+	}
+	if dir, err := os.Open(d.opts.Dir); err == nil {
+		_ = dir.Sync()
+		dir.Close()
+	}
+
+	if err := d.wal.Truncate(0); err != nil {
+		return err
+	}
+	d.size = 0
+	if _, err := d.wal.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+	if err := d.wal.Sync(); err != nil {
+		return err
+	}
+	d.unsynced = 0
+	d.sinceSnapshot = 0
+	// снапшот совпадает с памятью, журнал пуст — можно снова принимать изменения
+	d.failed = nil
+	return nil
+}
+
+func (s *MemoryStore) Snapshot() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.durable == nil {
+		return nil
+	}
+	return s.snapshotLocked()
+}
+
+func (s *MemoryStore) background() {
+	d := s.durable
+	defer d.done.Done()
+
+	var syncC, snapC <-chan time.Time
+	if d.opts.SyncInterval > 0 {
+		t := time.NewTicker(d.opts.SyncInterval)
+		defer t.Stop()
+		syncC = t.C
+	}
+	if d.opts.SnapshotInterval > 0 {
+		t := time.NewTicker(d.opts.SnapshotInterval)
+		defer t.Stop()
+		snapC = t.C
+	}
+
+	for {
+		select {
+		case <-d.stop:
+			return
+		case <-syncC:
+			s.mu.Lock()
+			if err := s.syncLocked(); err != nil {
+				log.Printf("wal: %v, refusing further writes", err)
+				d.failed = err
+			}
+			s.mu.Unlock()
+		case <-snapC:
+			s.mu.Lock()
+			if d.sinceSnapshot > 0 {
+				if err := s.snapshotLocked(); err != nil {
+					log.Printf("wal: snapshot failed: %v", err)
+				}
+			}
+			s.mu.Unlock()
+		}
+	}
+}
+
+func (s *MemoryStore) Close() error {
+	if s.durable == nil {
+		return nil
+	}
+	close(s.durable.stop)
+	s.durable.done.Wait()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	err := s.syncLocked()
+	if cerr := s.durable.wal.Close(); err == nil {
+		err = cerr
+	}
+	return err
+}
@@ -0,0 +1,310 @@
+package storage
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+)
+
+func TestOpenMemoryStore_ReplaysWAL(t *testing.T) {
+	dir := t.TempDir()
+	store, err := OpenMemoryStore(DurableOptions{Dir: dir})
+	if err != nil {
+		t.Fatalf("open: %v", err)
+	}
+	store.Create("Buy milk")
+	store.Create("Write code")
+	done := true
+	store.Update(1, TaskUpdatePayload{Done: &done})
+	store.Delete(2)
+	if err := store.Close(); err != nil {
+		t.Fatalf("close: %v", err)
+	}
+
+	reopened, err := OpenMemoryStore(DurableOptions{Dir: dir})
+	if err != nil {
+		t.Fatalf("reopen: %v", err)
+	}
+	defer reopened.Close()
+
+	task, err := reopened.Get(1)
+	if err != nil || !task.Done {
+		t.Fatalf("expected task 1 to be restored as done, got %+v, %v", task, err)
+	}
+	if _, err := reopened.Get(2); err == nil {
+		t.Errorf("expected task 2 to stay deleted")
+	}
+
+	created, _ := reopened.Create("Next")
+	if created.ID != 3 {
+		t.Errorf("expected id counter to continue from 3, got %d", created.ID)
+	}
+}
+
+func TestOpenMemoryStore_SnapshotCompactsWAL(t *testing.T) {
+	dir := t.TempDir()
+	store, err := OpenMemoryStore(DurableOptions{Dir: dir, SnapshotEvery: 2})
+	if err != nil {
+		t.Fatalf("open: %v", err)
+	}
+	store.Create("One")
+	store.Create("Two")
+	store.Create("Three")
+	store.Close()
+
+	f, err := os.Open(filepath.Join(dir, walFileName))
+	if err != nil {
+		t.Fatalf("open wal: %v", err)
+	}
+	rec, _, err := readWALRecord(f)
+	if err != nil || rec.ID != 3 {
+		t.Errorf("expected wal to start with the record after the snapshot, got %+v, %v", rec, err)
+	}
+	if _, _, err := readWALRecord(f); err != io.EOF {
+		t.Errorf("expected wal to hold a single record, got %v", err)
+	}
+	f.Close()
+
+	reopened, err := OpenMemoryStore(DurableOptions{Dir: dir})
+	if err != nil {
+		t.Fatalf("reopen: %v", err)
+	}
+	defer reopened.Close()
+	if n := len(reopened.List()); n != 3 {
+		t.Errorf("expected 3 tasks after snapshot + wal replay, got %d", n)
+	}
+}
+
+func TestOpenMemoryStore_TruncatesTornRecord(t *testing.T) {
+	dir := t.TempDir()
+	store, err := OpenMemoryStore(DurableOptions{Dir: dir})
+	if err != nil {
+		t.Fatalf("open: %v", err)
+	}
+	store.Create("Complete")
+	store.Close()
+
+	walPath := filepath.Join(dir, walFileName)
+	good, _ := os.Stat(walPath)
+	rec, _ := encodeWALRecord(walRecord{Op: opCreate, ID: 2, Task: &Task{ID: 2, Title: "Torn"}})
+	f, _ := os.OpenFile(walPath, os.O_WRONLY|os.O_APPEND, 0o644)
+	f.Write(rec[:len(rec)-3])
+	f.Close()
+
+	reopened, err := OpenMemoryStore(DurableOptions{Dir: dir})
+	if err != nil {
+		t.Fatalf("reopen with torn record: %v", err)
+	}
+	defer reopened.Close()
+
+	if n := len(reopened.List()); n != 1 {
+		t.Errorf("expected only the complete task, got %d", n)
+	}
+	info, _ := os.Stat(walPath)
+	if info.Size() != good.Size() {
+		t.Errorf("expected wal truncated to %d bytes, got %d", good.Size(), info.Size())
+	}
+}
+
+func TestOpenMemoryStore_RejectsCorruptRecordBeforeTail(t *testing.T) {
+	dir := t.TempDir()
+	store, err := OpenMemoryStore(DurableOptions{Dir: dir})
+	if err != nil {
+		t.Fatalf("open: %v", err)
+	}
+	store.Create("One")
+	store.Create("Two")
+	store.Create("Three")
+	store.Close()
+
+	walPath := filepath.Join(dir, walFileName)
+	data, _ := os.ReadFile(walPath)
+	_, first, _ := readWALRecord(bytes.NewReader(data))
+	data[first+walHeaderSize+2] ^= 0xff // байт внутри второй записи
+	os.WriteFile(walPath, data, 0o644)
+
+	if _, err := OpenMemoryStore(DurableOptions{Dir: dir}); err == nil {
+		t.Fatal("expected corrupt record in the middle of the wal to fail startup")
+	}
+	if info, _ := os.Stat(walPath); info.Size() != int64(len(data)) {
+		t.Errorf("expected wal to be left untouched, size %d -> %d", len(data), info.Size())
+	}
+}
+
+func TestOpenMemoryStore_RejectsCorruptHeaderLength(t *testing.T) {
+	for name, corrupt := range map[string]func(header []byte){
+		// старший бит: длина больше допустимой
+		"over limit": func(header []byte) { header[3] ^= 0x80 },
+		// правдоподобная длина, которая уводит за конец файла через следующие записи
+		"past eof": func(header []byte) { header[1] ^= 0x10 },
+	} {
+		t.Run(name, func(t *testing.T) {
+			dir := t.TempDir()
+			store, err := OpenMemoryStore(DurableOptions{Dir: dir})
+			if err != nil {
+				t.Fatalf("open: %v", err)
+			}
+			store.Create("One")
+			store.Create("Two")
+			store.Create("Three")
+			store.Close()
+
+			walPath := filepath.Join(dir, walFileName)
+			data, _ := os.ReadFile(walPath)
+			corrupt(data[:walHeaderSize])
+			os.WriteFile(walPath, data, 0o644)
+
+			if _, err := OpenMemoryStore(DurableOptions{Dir: dir}); err == nil {
+				t.Fatal("expected corrupt length in the first record to fail startup")
+			}
+			if info, _ := os.Stat(walPath); info.Size() != int64(len(data)) {
+				t.Errorf("expected wal to be left untouched, size %d -> %d", len(data), info.Size())
+			}
+		})
+	}
+}
+
+func TestOpenMemoryStore_TruncatesZeroFilledTail(t *testing.T) {
+	dir := t.TempDir()
+	store, err := OpenMemoryStore(DurableOptions{Dir: dir})
+	if err != nil {
+		t.Fatalf("open: %v", err)
+	}
+	store.Create("Complete")
+	store.Close()
+
+	// заголовок успел попасть на диск, а payload — только частично, дальше нули
+	walPath := filepath.Join(dir, walFileName)
+	rec, _ := encodeWALRecord(walRecord{Op: opCreate, ID: 2, Task: &Task{ID: 2, Title: "Torn"}})
+	tail := append(rec[:walHeaderSize+5:walHeaderSize+5], make([]byte, 10)...)
+	f, _ := os.OpenFile(walPath, os.O_WRONLY|os.O_APPEND, 0o644)
+	f.Write(tail)
+	f.Close()
+
+	reopened, err := OpenMemoryStore(DurableOptions{Dir: dir})
+	if err != nil {
+		t.Fatalf("reopen with zero-filled tail: %v", err)
+	}
+	defer reopened.Close()
+	if n := len(reopened.List()); n != 1 {
+		t.Errorf("expected only the complete task, got %d", n)
+	}
+}
+
+func TestOpenMemoryStore_TruncatesCorruptLastRecord(t *testing.T) {
+	dir := t.TempDir()
+	store, err := OpenMemoryStore(DurableOptions{Dir: dir})
+	if err != nil {
+		t.Fatalf("open: %v", err)
+	}
+	store.Create("One")
+	store.Create("Two")
+	store.Close()
+
+	walPath := filepath.Join(dir, walFileName)
+	data, _ := os.ReadFile(walPath)
+	data[len(data)-2] ^= 0xff
+	os.WriteFile(walPath, data, 0o644)
+
+	reopened, err := OpenMemoryStore(DurableOptions{Dir: dir})
+	if err != nil {
+		t.Fatalf("reopen: %v", err)
+	}
+	defer reopened.Close()
+	if n := len(reopened.List()); n != 1 {
+		t.Errorf("expected only the first task, got %d", n)
+	}
+}
+
+// faultyWAL пропускает вызовы к настоящему файлу, пока не включён сбой.
+type faultyWAL struct {
+	walFile
+	failWrite bool
+	failSync  bool
+}
+
+func (f *faultyWAL) Write(p []byte) (int, error) {
+	if f.failWrite {
+		// часть записи успевает попасть в файл
+		n, _ := f.walFile.Write(p[:len(p)/2])
+		return n, errors.New("disk full")
+	}
+	return f.walFile.Write(p)
+}
+
+func (f *faultyWAL) Sync() error {
+	if f.failSync {
+		return errors.New("io error")
+	}
+	return f.walFile.Sync()
+}
+
+func TestMemoryStore_RollsBackFailedWALWrite(t *testing.T) {
+	dir := t.TempDir()
+	store, err := OpenMemoryStore(DurableOptions{Dir: dir})
+	if err != nil {
+		t.Fatalf("open: %v", err)
+	}
+	wal := &faultyWAL{walFile: store.durable.wal}
+	store.durable.wal = wal
+
+	store.Create("Before")
+	wal.failWrite = true
+	if _, err := store.Create("Lost"); err == nil {
+		t.Fatal("expected create to fail")
+	}
+	wal.failWrite = false
+	if _, err := store.Create("After"); err != nil {
+		t.Fatalf("expected store to keep working after a failed write: %v", err)
+	}
+	store.Close()
+
+	reopened, err := OpenMemoryStore(DurableOptions{Dir: dir})
+	if err != nil {
+		t.Fatalf("reopen: %v", err)
+	}
+	defer reopened.Close()
+	var titles []string
+	for _, task := range reopened.List() {
+		titles = append(titles, task.Title)
+	}
+	sort.Strings(titles)
+	if strings.Join(titles, ",") != "After,Before" {
+		t.Errorf("expected failed create to be rolled back, got %v", titles)
+	}
+}
+
+func TestMemoryStore_FailedSyncStopsWrites(t *testing.T) {
+	dir := t.TempDir()
+	store, err := OpenMemoryStore(DurableOptions{Dir: dir})
+	if err != nil {
+		t.Fatalf("open: %v", err)
+	}
+	wal := &faultyWAL{walFile: store.durable.wal}
+	store.durable.wal = wal
+
+	store.Create("Kept")
+	wal.failSync = true
+	if _, err := store.Create("Lost"); err == nil {
+		t.Fatal("expected create to fail")
+	}
+	wal.failSync = false
+	if _, err := store.Create("Refused"); err == nil {
+		t.Fatal("expected writes to be refused after a failed sync")
+	}
+	store.Close()
+
+	reopened, err := OpenMemoryStore(DurableOptions{Dir: dir})
+	if err != nil {
+		t.Fatalf("reopen: %v", err)
+	}
+	defer reopened.Close()
+	if tasks := reopened.List(); len(tasks) != 1 || tasks[0].Title != "Kept" {
+		t.Errorf("expected only the acknowledged task, got %+v", tasks)
+	}
+}
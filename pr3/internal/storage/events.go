@@ -0,0 +1,193 @@
+package storage
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+type EventType string
+
+const (
+	EventCreated EventType = "created"
+	EventUpdated EventType = "updated"
+	EventDeleted EventType = "deleted"
+)
+
+const (
+	defaultEventHistory = 256
+	defaultEventBuffer  = 64
+)
+
+var ErrInvalidEventID = errors.New("invalid event id")
+
+// EventID — позиция в потоке событий. Seq растёт в пределах процесса, а Epoch
+// меняется при каждом запуске, поэтому номер из прошлого запуска не спутать с текущим.
+type EventID struct {
+	Epoch string
+	Seq   uint64
+}
+
+func (id EventID) String() string {
+	return id.Epoch + "-" + strconv.FormatUint(id.Seq, 10)
+}
+
+// ParseEventID разбирает "<epoch>-<seq>". Голое число (формат до появления эпох)
+// принимается с пустой эпохой и потому всегда считается чужим.
+func ParseEventID(s string) (EventID, error) {
+	epoch, seq, ok := strings.Cut(s, "-")
+	if !ok {
+		epoch, seq = "", s
+	}
+	n, err := strconv.ParseUint(seq, 10, 64)
+	if err != nil {
+		return EventID{}, ErrInvalidEventID
+	}
+	return EventID{Epoch: epoch, Seq: n}, nil
+}
+
+// Event — изменение задачи.
+type Event struct {
+	ID     EventID   `json:"-"`
+	Type   EventType `json:"type"`
+	TaskID int64     `json:"task_id"`
+	Task   *Task     `json:"task,omitempty"`
+	Time   time.Time `json:"time"`
+}
+
+// Broker раздаёт события подписчикам. У каждого подписчика свой буфер;
+// если он переполнен, подписчик отключается, а не задерживает запись в хранилище.
+// Последние события хранятся в кольцевом буфере для продолжения по Last-Event-ID.
+type Broker struct {
+	mu      sync.Mutex
+	epoch   string
+	lastSeq uint64
+	history []Event
+	next    int
+	full    bool
+	buffer  int
+	subs    map[*Subscription]struct{}
+	closed  bool
+}
+
+type Subscription struct {
+	C      <-chan Event
+	ch     chan Event
+	broker *Broker
+}
+
+func NewBroker(history, buffer int) *Broker {
+	if history <= 0 {
+		history = defaultEventHistory
+	}
+	if buffer <= 0 {
+		buffer = defaultEventBuffer
+	}
+	return &Broker{
+		epoch:   newEpoch(),
+		history: make([]Event, history),
+		buffer:  buffer,
+		subs:    make(map[*Subscription]struct{}),
+	}
+}
+
+func newEpoch() string {
+	buf := make([]byte, 6)
+	if _, err := rand.Read(buf); err != nil {
+		return strconv.FormatInt(time.Now().UnixNano(), 36)
+	}
+	return hex.EncodeToString(buf)
+}
+
+func (b *Broker) publish(typ EventType, id int64, t *Task) {
+	e := Event{Type: typ, TaskID: id, Time: time.Now().UTC()}
+	if t != nil {
+		e.Task = t.clone()
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.lastSeq++
+	e.ID = EventID{Epoch: b.epoch, Seq: b.lastSeq}
+	b.history[b.next] = e
+	b.next = (b.next + 1) % len(b.history)
+	if b.next == 0 {
+		b.full = true
+	}
+
+	for sub := range b.subs {
+		select {
+		case sub.ch <- e:
+		default:
+			delete(b.subs, sub)
+			close(sub.ch)
+		}
+	}
+}
+
+// Subscribe подписывает на новые события. Если задан last, сначала возвращаются
+// пропущенные события из истории; complete=false значит, что часть из них уже
+// вытеснена или last из другого запуска сервера, и клиенту нужно перечитать состояние целиком.
+func (b *Broker) Subscribe(last EventID) (sub *Subscription, backlog []Event, complete bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	complete = true
+	switch {
+	case last == EventID{}:
+	case last.Epoch != b.epoch || last.Seq > b.lastSeq:
+		complete = false
+	case last.Seq < b.lastSeq:
+		backlog = b.since(last.Seq)
+		complete = len(backlog) > 0 && backlog[0].ID.Seq == last.Seq+1
+	}
+
+	ch := make(chan Event, b.buffer)
+	sub = &Subscription{C: ch, ch: ch, broker: b}
+	if b.closed {
+		close(ch)
+	} else {
+		b.subs[sub] = struct{}{}
+	}
+	return sub, backlog, complete
+}
+
+func (b *Broker) since(lastSeq uint64) []Event {
+	var out []Event
+	start, n := 0, b.next
+	if b.full {
+		start, n = b.next, len(b.history)
+	}
+	for i := 0; i < n; i++ {
+		e := b.history[(start+i)%len(b.history)]
+		if e.ID.Seq > lastSeq {
+			out = append(out, e)
+		}
+	}
+	return out
+}
+
+func (s *Subscription) Close() {
+	b := s.broker
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if _, ok := b.subs[s]; ok {
+		delete(b.subs, s)
+		close(s.ch)
+	}
+}
+
+// Close отключает всех подписчиков, чтобы долгие SSE-запросы завершились при остановке сервера.
+func (b *Broker) Close() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.closed = true
+	for sub := range b.subs {
+		delete(b.subs, sub)
+		close(sub.ch)
+	}
+}
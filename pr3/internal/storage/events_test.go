@@ -0,0 +1,53 @@
+package storage
+
+import "testing"
+
+func TestBroker_ResumeAndDropSlowSubscriber(t *testing.T) {
+	b := NewBroker(2, 1)
+	slow, _, _ := b.Subscribe(EventID{})
+
+	for i := int64(1); i <= 4; i++ {
+		b.publish(EventCreated, i, &Task{ID: i})
+	}
+
+	// буфер на одно событие: первое доставлено, на втором подписчик отключён
+	if e := <-slow.C; e.ID.Seq != 1 {
+		t.Fatalf("expected first event, got %s", e.ID)
+	}
+	if _, ok := <-slow.C; ok {
+		t.Fatalf("expected slow subscriber to be dropped")
+	}
+
+	sub, backlog, complete := b.Subscribe(EventID{Epoch: b.epoch, Seq: 2})
+	defer sub.Close()
+	if !complete || len(backlog) != 2 || backlog[0].ID.Seq != 3 || backlog[1].ID.Seq != 4 {
+		t.Errorf("expected events 3 and 4 from history, got %+v (complete=%v)", backlog, complete)
+	}
+
+	if _, _, complete := b.Subscribe(EventID{Epoch: b.epoch, Seq: 1}); complete {
+		t.Errorf("expected event 2 to be evicted from history of size 2")
+	}
+}
+
+func TestBroker_ResetsOnForeignEpoch(t *testing.T) {
+	previous := NewBroker(0, 0)
+	b := NewBroker(0, 0)
+	for i := int64(1); i <= 5; i++ {
+		b.publish(EventCreated, i, &Task{ID: i})
+	}
+
+	// тот же номер из прошлого запуска не должен досылать чужие события
+	sub, backlog, complete := b.Subscribe(EventID{Epoch: previous.epoch, Seq: 2})
+	defer sub.Close()
+	if complete || len(backlog) != 0 {
+		t.Errorf("expected reset for an id from another epoch, got %d events (complete=%v)", len(backlog), complete)
+	}
+
+	legacy, err := ParseEventID("2")
+	if err != nil {
+		t.Fatalf("parse legacy id: %v", err)
+	}
+	if _, _, complete := b.Subscribe(legacy); complete {
+		t.Errorf("expected reset for a bare numeric id")
+	}
+}
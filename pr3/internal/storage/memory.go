@@ -2,59 +2,267 @@ package storage
 
 import (
 	"errors"
-	"fmt"
+	"maps"
 	"sync"
+	"time"
 )
 
+type Priority string
+
+const (
+	PriorityNone   Priority = ""
+	PriorityLow    Priority = "low"
+	PriorityNormal Priority = "normal"
+	PriorityHigh   Priority = "high"
+)
+
+func (p Priority) Valid() bool {
+	switch p {
+	case PriorityNone, PriorityLow, PriorityNormal, PriorityHigh:
+		return true
+	}
+	return false
+}
+
 type Task struct {
-	ID    int64  `json:"id"`
-	Title string `json:"title"`
-	Done  bool   `json:"done"`
+	ID          int64      `json:"id"`
+	Title       string     `json:"title"`
+	Description string     `json:"description"`
+	Priority    Priority   `json:"priority"`
+	Done        bool       `json:"done"`
+	DueDate     *time.Time `json:"due_date"`
+	BlockedBy   []int64    `json:"blocked_by,omitempty"`
+	// Projects, Contexts и Extensions — метки +project, @context и key:value из todo.txt.
+	Projects    []string          `json:"projects,omitempty"`
+	Contexts    []string          `json:"contexts,omitempty"`
+	Extensions  map[string]string `json:"extensions,omitempty"`
+	CreatedAt   time.Time         `json:"created_at"`
+	UpdatedAt   time.Time         `json:"updated_at"`
+	CompletedAt *time.Time        `json:"completed_at"`
+	// Version растёт на единицу при каждом изменении задачи.
+	Version int64 `json:"version"`
+}
+
+func (t *Task) clone() *Task {
+	c := *t
+	return &c
+}
+
+var (
+	ErrNotFound        = errors.New("not found")
+	ErrVersionMismatch = errors.New("version mismatch")
+)
+
+// VersionMatch — условие для оптимистичной блокировки: Any требует лишь
+// существования задачи, иначе текущая версия должна совпасть с одной из Versions.
+type VersionMatch struct {
+	Any      bool
+	Versions []int64
+}
+
+func (m *VersionMatch) check(t *Task, found bool) error {
+	if m == nil {
+		if !found {
+			return ErrNotFound
+		}
+		return nil
+	}
+	if !found {
+		return ErrVersionMismatch
+	}
+	if m.Any {
+		return nil
+	}
+	for _, v := range m.Versions {
+		if v == t.Version {
+			return nil
+		}
+	}
+	return ErrVersionMismatch
 }
 
 type MemoryStore struct {
-	mu    sync.RWMutex
-	auto  int64
+	mu   sync.RWMutex
+	auto int64
+	// Задачи в tasks не меняются на месте: изменение кладёт новую копию,
+	// а наружу отдаются копии, чтобы тело ответа и ETag не расходились.
 	tasks map[int64]*Task
+
+	durable *durability
+	events  *Broker
 }
 
 func NewMemoryStore() *MemoryStore {
 	return &MemoryStore{
-		tasks: make(map[int64]*Task),
+		tasks:  make(map[int64]*Task),
+		events: NewBroker(0, 0),
 	}
 }
 
-func (s *MemoryStore) Create(title string) *Task {
+// Events — поток изменений задач. События публикуются под блокировкой хранилища,
+// поэтому их порядок совпадает с порядком изменений.
+func (s *MemoryStore) Events() *Broker {
+	return s.events
+}
+
+type NewTask struct {
+	Title       string
+	Description string
+	Priority    Priority
+	DueDate     *time.Time
+	Projects    []string
+	Contexts    []string
+	Extensions  map[string]string
+	// Done, CreatedAt и CompletedAt задаются при импорте; по умолчанию задача
+	// открыта и создана сейчас.
+	Done        bool
+	CreatedAt   *time.Time
+	CompletedAt *time.Time
+}
+
+func (s *MemoryStore) Create(title string) (*Task, error) {
+	return s.CreateTask(NewTask{Title: title})
+}
+
+func (s *MemoryStore) CreateTask(in NewTask) (*Task, error) {
 	s.mu.Lock()
 	defer s.mu.Unlock()
-	s.auto++
-	t := &Task{ID: s.auto, Title: title, Done: false}
-	s.tasks[t.ID] = t
+	x := s.begin()
+	t := x.create(in)
+	if err := x.commit(); err != nil {
+		return nil, err
+	}
+	return t.clone(), nil
+}
+
+func newTask(id int64, in NewTask, now time.Time) *Task {
+	t := &Task{
+		ID:          id,
+		Title:       in.Title,
+		Description: in.Description,
+		Priority:    in.Priority,
+		DueDate:     in.DueDate,
+		Projects:    in.Projects,
+		Contexts:    in.Contexts,
+		Extensions:  in.Extensions,
+		Done:        in.Done,
+		CreatedAt:   now,
+		UpdatedAt:   now,
+		Version:     1,
+	}
+	if in.CreatedAt != nil {
+		t.CreatedAt = *in.CreatedAt
+	}
+	if t.Done {
+		t.CompletedAt = in.CompletedAt
+		if t.CompletedAt == nil {
+			t.CompletedAt = &now
+		}
+	}
 	return t
 }
 
+// TaskUpdatePayload описывает частичное изменение: nil-поля не трогаются.
+// Для срока выполнения ClearDueDate сбрасывает его, так как nil означает «не менять».
 type TaskUpdatePayload struct {
-	Done bool `json:"done"`
+	Title        *string
+	Description  *string
+	Priority     *Priority
+	Done         *bool
+	DueDate      *time.Time
+	ClearDueDate bool
+	Projects     *[]string
+	Contexts     *[]string
+	// Extensions сливается с текущими расширениями: nil-значение удаляет ключ.
+	// ClearExtensions сначала удаляет все.
+	Extensions      map[string]*string
+	ClearExtensions bool
+}
+
+func (p TaskUpdatePayload) apply(t *Task) {
+	if p.Title != nil {
+		t.Title = *p.Title
+	}
+	if p.Description != nil {
+		t.Description = *p.Description
+	}
+	if p.Priority != nil {
+		t.Priority = *p.Priority
+	}
+	if p.Done != nil {
+		t.Done = *p.Done
+	}
+	if p.ClearDueDate {
+		t.DueDate = nil
+	}
+	if p.DueDate != nil {
+		due := *p.DueDate
+		t.DueDate = &due
+	}
+	if p.Projects != nil {
+		t.Projects = *p.Projects
+	}
+	if p.Contexts != nil {
+		t.Contexts = *p.Contexts
+	}
+	if p.ClearExtensions || p.Extensions != nil {
+		ext := map[string]string{}
+		if !p.ClearExtensions {
+			maps.Copy(ext, t.Extensions)
+		}
+		for k, v := range p.Extensions {
+			if v == nil {
+				delete(ext, k)
+			} else {
+				ext[k] = *v
+			}
+		}
+		if len(ext) == 0 {
+			ext = nil
+		}
+		t.Extensions = ext
+	}
+}
+
+func (s *MemoryStore) Update(id int64, payload TaskUpdatePayload) (*Task, error) {
+	return s.UpdateIf(id, nil, payload)
 }
 
-func (s *MemoryStore) Update(id int64, payload TaskUpdatePayload) *Task {
+// UpdateIf применяет изменение, только если задача удовлетворяет match (nil — без проверки).
+// Проверка и запись выполняются под одной блокировкой.
+func (s *MemoryStore) UpdateIf(id int64, match *VersionMatch, payload TaskUpdatePayload) (*Task, error) {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
-	t, ok := s.tasks[id]
-	if !ok {
-		return nil
+	x := s.begin()
+	t, err := x.update(id, match, payload)
+	if err != nil {
+		return nil, err
+	}
+	if err := x.commit(); err != nil {
+		return nil, err
 	}
-	fmt.Print(t.ID, t.Done)
+	return t.clone(), nil
+}
 
-	t.Done = payload.Done
-	return t
+func (s *MemoryStore) Delete(id int64) error {
+	err := s.DeleteIf(id, nil)
+	if errors.Is(err, ErrNotFound) {
+		return nil
+	}
+	return err
 }
 
-func (s *MemoryStore) Delete(id int64) {
+// DeleteIf удаляет задачу, если она удовлетворяет match. Без условия отсутствующая
+// задача даёт ErrNotFound, с условием — ErrVersionMismatch.
+func (s *MemoryStore) DeleteIf(id int64, match *VersionMatch) error {
 	s.mu.Lock()
 	defer s.mu.Unlock()
-	delete(s.tasks, id)
+	x := s.begin()
+	if err := x.delete(id, match); err != nil {
+		return err
+	}
+	return x.commit()
 }
 
 func (s *MemoryStore) Get(id int64) (*Task, error) {
@@ -62,9 +270,23 @@ func (s *MemoryStore) Get(id int64) (*Task, error) {
 	defer s.mu.RUnlock()
 	t, ok := s.tasks[id]
 	if !ok {
-		return nil, errors.New("not found")
+		return nil, ErrNotFound
 	}
-	return t, nil
+	return t.clone(), nil
+}
+
+// GetMany возвращает существующие задачи из ids в том же порядке за одну
+// блокировку; отсутствующие пропускаются.
+func (s *MemoryStore) GetMany(ids []int64) []*Task {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	out := make([]*Task, 0, len(ids))
+	for _, id := range ids {
+		if t, ok := s.tasks[id]; ok {
+			out = append(out, t.clone())
+		}
+	}
+	return out
 }
 
 func (s *MemoryStore) List() []*Task {
@@ -72,7 +294,7 @@ func (s *MemoryStore) List() []*Task {
 	defer s.mu.RUnlock()
 	out := make([]*Task, 0, len(s.tasks))
 	for _, t := range s.tasks {
-		out = append(out, t)
+		out = append(out, t.clone())
 	}
 	return out
 }
@@ -0,0 +1,222 @@
+package storage
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"sort"
+	"strings"
+	"time"
+)
+
+var (
+	ErrInvalidSort   = errors.New("invalid sort")
+	ErrInvalidCursor = errors.New("invalid cursor")
+)
+
+// Поля сортировки; префикс "-" означает обратный порядок.
+var sortFields = map[string]bool{"id": true, "title": true, "due": true, "created": true}
+
+// ListQuery описывает выборку задач. Offset и курсоры взаимоисключающие:
+// After — страница после задачи-курсора, Before — страница перед ней.
+type ListQuery struct {
+	Query string
+	Done  *bool
+	// Ready отбирает задачи без открытых блокеров (true) или с ними (false).
+	Ready  *bool
+	Sort   string
+	Limit  int
+	Offset int
+	After  *Cursor
+	Before *Cursor
+}
+
+type ListResult struct {
+	Tasks []*Task
+	Total int
+	// Next и Prev — курсоры соседних страниц, nil если в ту сторону задач нет.
+	Next *Cursor
+	Prev *Cursor
+}
+
+// Cursor хранит ключ сортировки задачи, а не её позицию, поэтому не «съезжает»
+// при вставках и удалениях между запросами.
+type Cursor struct {
+	Sort      string     `json:"s"`
+	ID        int64      `json:"id"`
+	Title     string     `json:"t,omitempty"`
+	DueDate   *time.Time `json:"d,omitempty"`
+	CreatedAt time.Time  `json:"c,omitempty"`
+	// Backward — курсор указывает на страницу перед задачей, а не после неё.
+	Backward bool `json:"b,omitempty"`
+}
+
+func NewCursor(sort string, t *Task) *Cursor {
+	return &Cursor{Sort: sort, ID: t.ID, Title: t.Title, DueDate: t.DueDate, CreatedAt: t.CreatedAt}
+}
+
+// newBackwardCursor — курсор на страницу перед задачей t.
+func newBackwardCursor(sort string, t *Task) *Cursor {
+	c := NewCursor(sort, t)
+	c.Backward = true
+	return c
+}
+
+func (c *Cursor) task() *Task {
+	return &Task{ID: c.ID, Title: c.Title, DueDate: c.DueDate, CreatedAt: c.CreatedAt}
+}
+
+func (c *Cursor) Encode() string {
+	b, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+func DecodeCursor(s string) (*Cursor, error) {
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+	var c Cursor
+	if err := json.Unmarshal(b, &c); err != nil {
+		return nil, ErrInvalidCursor
+	}
+	return &c, nil
+}
+
+func NormalizeSort(s string) (string, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return "id", nil
+	}
+	if !sortFields[strings.TrimPrefix(s, "-")] {
+		return "", ErrInvalidSort
+	}
+	return s, nil
+}
+
+// lessFunc возвращает строгий порядок задач; при равенстве ключа порядок задаёт id,
+// а задачи без срока всегда идут в конце.
+func lessFunc(sortBy string) func(a, b *Task) bool {
+	desc := strings.HasPrefix(sortBy, "-")
+	field := strings.TrimPrefix(sortBy, "-")
+
+	cmp := func(a, b *Task) int {
+		switch field {
+		case "title":
+			return strings.Compare(strings.ToLower(a.Title), strings.ToLower(b.Title))
+		case "due":
+			switch {
+			case a.DueDate == nil && b.DueDate == nil:
+				return 0
+			case a.DueDate == nil:
+				if desc {
+					return -1
+				}
+				return 1
+			case b.DueDate == nil:
+				if desc {
+					return 1
+				}
+				return -1
+			}
+			return a.DueDate.Compare(*b.DueDate)
+		case "created":
+			return a.CreatedAt.Compare(b.CreatedAt)
+		}
+		return 0
+	}
+
+	return func(a, b *Task) bool {
+		c := cmp(a, b)
+		if c == 0 {
+			c = compareID(a.ID, b.ID)
+		}
+		if desc {
+			return c > 0
+		}
+		return c < 0
+	}
+}
+
+func compareID(a, b int64) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+	return 0
+}
+
+func (q ListQuery) match(t *Task) bool {
+	if q.Done != nil && t.Done != *q.Done {
+		return false
+	}
+	if q.Query != "" && !strings.Contains(strings.ToLower(t.Title), strings.ToLower(q.Query)) {
+		return false
+	}
+	return true
+}
+
+// Query фильтрует, сортирует и режет задачи на страницы под одной блокировкой чтения.
+// Total — число задач, прошедших фильтр, без учёта пагинации.
+func (s *MemoryStore) Query(q ListQuery) (ListResult, error) {
+	sortBy, err := NormalizeSort(q.Sort)
+	if err != nil {
+		return ListResult{}, err
+	}
+	for _, c := range []*Cursor{q.After, q.Before} {
+		if c != nil && c.Sort != sortBy {
+			return ListResult{}, ErrInvalidCursor
+		}
+	}
+	less := lessFunc(sortBy)
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	matched := make([]*Task, 0)
+	for _, t := range s.tasks {
+		if !q.match(t) || (q.Ready != nil && s.readyLocked(t) != *q.Ready) {
+			continue
+		}
+		matched = append(matched, t)
+	}
+	sort.Slice(matched, func(i, j int) bool { return less(matched[i], matched[j]) })
+
+	// [start, end) — окно выборки до применения limit
+	start, end := 0, len(matched)
+	if q.After != nil {
+		pivot := q.After.task()
+		start = sort.Search(len(matched), func(i int) bool { return less(pivot, matched[i]) })
+	}
+	if q.Before != nil {
+		pivot := q.Before.task()
+		end = sort.Search(len(matched), func(i int) bool { return !less(matched[i], pivot) })
+	}
+	start = min(start+q.Offset, end)
+	if q.Limit > 0 && end-start > q.Limit {
+		// назад страница набирается от курсора, вперёд — от начала окна
+		if q.Before != nil {
+			start = end - q.Limit
+		} else {
+			end = start + q.Limit
+		}
+	}
+
+	res := ListResult{Total: len(matched)}
+	page := matched[start:end]
+	if q.Limit > 0 && len(page) > 0 {
+		if end < len(matched) {
+			res.Next = NewCursor(sortBy, page[len(page)-1])
+		}
+		if start > 0 {
+			res.Prev = newBackwardCursor(sortBy, page[0])
+		}
+	}
+	res.Tasks = make([]*Task, len(page))
+	for i, t := range page {
+		res.Tasks[i] = t.clone()
+	}
+	return res, nil
+}
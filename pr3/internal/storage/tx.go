@@ -0,0 +1,149 @@
+package storage
+
+import (
+	"slices"
+	"time"
+)
+
+// tx копит изменения поверх текущего состояния хранилища и применяет их разом:
+// одна запись в журнале (или opBatch для нескольких), затем события.
+// Используется только под s.mu.Lock.
+type tx struct {
+	s       *MemoryStore
+	staged  map[int64]*Task // nil — задача удалена
+	records []walRecord
+	auto    int64
+	now     time.Time
+}
+
+func (s *MemoryStore) begin() *tx {
+	return &tx{
+		s:      s,
+		staged: make(map[int64]*Task),
+		auto:   s.auto,
+		now:    time.Now().UTC(),
+	}
+}
+
+func (x *tx) get(id int64) (*Task, bool) {
+	if t, ok := x.staged[id]; ok {
+		return t, t != nil
+	}
+	t, ok := x.s.tasks[id]
+	return t, ok
+}
+
+// each обходит задачи с учётом ещё не применённых изменений.
+func (x *tx) each(fn func(t *Task)) {
+	for id, t := range x.s.tasks {
+		if _, ok := x.staged[id]; !ok {
+			fn(t)
+		}
+	}
+	for _, t := range x.staged {
+		if t != nil {
+			fn(t)
+		}
+	}
+}
+
+func (x *tx) create(in NewTask) *Task {
+	x.auto++
+	t := newTask(x.auto, in, x.now)
+	x.staged[t.ID] = t
+	x.records = append(x.records, walRecord{Op: opCreate, ID: t.ID, Task: t})
+	return t
+}
+
+// put сохраняет изменённую копию задачи с новой версией.
+func (x *tx) put(t *Task) *Task {
+	t.UpdatedAt = x.now
+	t.Version++
+	x.staged[t.ID] = t
+	x.records = append(x.records, walRecord{Op: opUpdate, ID: t.ID, Task: t})
+	return t
+}
+
+func (x *tx) update(id int64, match *VersionMatch, payload TaskUpdatePayload) (*Task, error) {
+	t, ok := x.get(id)
+	if err := match.check(t, ok); err != nil {
+		return nil, err
+	}
+	updated := *t
+	payload.apply(&updated)
+	if updated.Done && !t.Done {
+		if open := x.openBlockers(&updated); len(open) > 0 {
+			return nil, &BlockedError{Blockers: open}
+		}
+		completed := x.now
+		updated.CompletedAt = &completed
+	}
+	if !updated.Done && t.Done {
+		if done := x.doneDependents(id); len(done) > 0 {
+			return nil, &DoneDependentsError{Dependents: done}
+		}
+	}
+	if !updated.Done {
+		updated.CompletedAt = nil
+	}
+	return x.put(&updated), nil
+}
+
+// delete удаляет задачу и убирает её из blocked_by зависящих от неё задач.
+func (x *tx) delete(id int64, match *VersionMatch) error {
+	t, ok := x.get(id)
+	if err := match.check(t, ok); err != nil {
+		return err
+	}
+	var dependents []*Task
+	x.each(func(d *Task) {
+		if slices.Contains(d.BlockedBy, id) {
+			dependents = append(dependents, d)
+		}
+	})
+	for _, d := range dependents {
+		updated := *d
+		updated.BlockedBy = slices.DeleteFunc(slices.Clone(d.BlockedBy), func(b int64) bool { return b == id })
+		x.put(&updated)
+	}
+	x.staged[id] = nil
+	x.records = append(x.records, walRecord{Op: opDelete, ID: id})
+	return nil
+}
+
+func (x *tx) commit() error {
+	s := x.s
+	switch len(x.records) {
+	case 0:
+		return nil
+	case 1:
+		if err := s.appendWAL(x.records[0]); err != nil {
+			return err
+		}
+	default:
+		if err := s.appendWAL(walRecord{Op: opBatch, Records: x.records}); err != nil {
+			return err
+		}
+	}
+
+	for id, t := range x.staged {
+		if t == nil {
+			delete(s.tasks, id)
+		} else {
+			s.tasks[id] = t
+		}
+	}
+	s.auto = x.auto
+	for _, rec := range x.records {
+		switch rec.Op {
+		case opCreate:
+			s.events.publish(EventCreated, rec.ID, rec.Task)
+		case opUpdate:
+			s.events.publish(EventUpdated, rec.ID, rec.Task)
+		case opDelete:
+			s.events.publish(EventDeleted, rec.ID, nil)
+		}
+	}
+	s.maybeSnapshotLocked()
+	return nil
+}
@@ -0,0 +1,181 @@
+package storage
+
+import (
+	"bufio"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"os"
+	"slices"
+)
+
+const (
+	opCreate = "create"
+	opUpdate = "update"
+	opDelete = "delete"
+	// opBatch хранит несколько записей в одной, чтобы атомарный пакет
+	// после сбоя восстановился либо целиком, либо никак.
+	opBatch = "batch"
+)
+
+type walRecord struct {
+	Op      string      `json:"op"`
+	ID      int64       `json:"id"`
+	Task    *Task       `json:"task,omitempty"`
+	Records []walRecord `json:"records,omitempty"`
+}
+
+// Формат записи: [длина payload uint32][crc32 payload uint32][payload JSON].
+const walHeaderSize = 8
+
+var crcTable = crc32.MakeTable(crc32.Castagnoli)
+
+func encodeWALRecord(rec walRecord) ([]byte, error) {
+	payload, err := json.Marshal(rec)
+	if err != nil {
+		return nil, err
+	}
+	buf := make([]byte, walHeaderSize+len(payload))
+	binary.LittleEndian.PutUint32(buf[0:4], uint32(len(payload)))
+	binary.LittleEndian.PutUint32(buf[4:8], crc32.Checksum(payload, crcTable))
+	copy(buf[walHeaderSize:], payload)
+	return buf, nil
+}
+
+const maxWALRecordSize = 64 << 20
+
+var (
+	// errTornRecord — запись обрывается концом файла (сбой посреди записи).
+	errTornRecord = errors.New("torn wal record")
+	// errCorruptRecord — не сходится контрольная сумма, не разбирается JSON,
+	// длина в заголовке неправдоподобна или уводит за конец файла мимо самой записи.
+	errCorruptRecord = errors.New("corrupt wal record")
+)
+
+// readWALRecord возвращает запись и её длину в байтах. Для errCorruptRecord длина —
+// заявленная в заголовке, чтобы вызывающий мог понять, есть ли за записью ещё данные.
+func readWALRecord(r io.Reader) (walRecord, int64, error) {
+	var rec walRecord
+	header := make([]byte, walHeaderSize)
+	if _, err := io.ReadFull(r, header); err != nil {
+		if err == io.EOF {
+			return rec, 0, io.EOF
+		}
+		if err == io.ErrUnexpectedEOF {
+			return rec, 0, errTornRecord
+		}
+		return rec, 0, err
+	}
+
+	size := binary.LittleEndian.Uint32(header[0:4])
+	sum := binary.LittleEndian.Uint32(header[4:8])
+	n := int64(walHeaderSize) + int64(size)
+	if size > maxWALRecordSize {
+		return rec, n, fmt.Errorf("%w: record size %d exceeds limit", errCorruptRecord, size)
+	}
+
+	payload := make([]byte, size)
+	if m, err := io.ReadFull(r, payload); err != nil {
+		if err != io.EOF && err != io.ErrUnexpectedEOF {
+			return rec, 0, err
+		}
+		if !tornPayload(payload[:m]) {
+			return rec, n, fmt.Errorf("%w: record size %d runs past the following records", errCorruptRecord, size)
+		}
+		return rec, 0, errTornRecord
+	}
+	if crc32.Checksum(payload, crcTable) != sum {
+		return rec, n, fmt.Errorf("%w: checksum mismatch", errCorruptRecord)
+	}
+	if err := json.Unmarshal(payload, &rec); err != nil {
+		return rec, n, fmt.Errorf("%w: %v", errCorruptRecord, err)
+	}
+	return rec, n, nil
+}
+
+// tornPayload сообщает, похожи ли байты на начало оборванной записи: json.Marshal не пишет
+// управляющих символов, поэтому допустимы только JSON и, возможно, нули до конца файла
+// (размер файла успел увеличиться, а данные — нет). Если за записью оказался заголовок
+// следующей, значит испорчена длина, а не оборван хвост.
+func tornPayload(p []byte) bool {
+	for i, b := range p {
+		if b < 0x20 {
+			return !slices.ContainsFunc(p[i:], func(b byte) bool { return b != 0 })
+		}
+	}
+	return true
+}
+
+// replayWAL применяет записи журнала к tasks и возвращает смещение конца последней целой записи.
+// Оборванную последнюю запись или запись, которая заканчивается ровно на конце файла,
+// но не сходится по контрольной сумме (сбой посреди записи), вызывающий код обрезает.
+// Любая другая испорченная запись — ошибка: обрезка потеряла бы подтверждённые записи после неё.
+func replayWAL(f *os.File, tasks map[int64]*Task, auto *int64) (int64, bool, error) {
+	info, err := f.Stat()
+	if err != nil {
+		return 0, false, err
+	}
+	if _, err := f.Seek(0, io.SeekStart); err != nil {
+		return 0, false, err
+	}
+	r := bufio.NewReader(f)
+
+	var offset int64
+	for {
+		rec, n, err := readWALRecord(r)
+		if err == io.EOF {
+			return offset, false, nil
+		}
+		if errors.Is(err, errTornRecord) {
+			return offset, true, nil
+		}
+		if errors.Is(err, errCorruptRecord) {
+			if offset+n == info.Size() {
+				return offset, true, nil
+			}
+			return offset, false, fmt.Errorf("wal record at offset %d: %w", offset, err)
+		}
+		if err != nil {
+			return offset, false, err
+		}
+		if err := applyWALRecord(rec, tasks, auto); err != nil {
+			return offset, false, fmt.Errorf("wal record at offset %d: %w", offset, err)
+		}
+		offset += n
+	}
+}
+
+func applyWALRecord(rec walRecord, tasks map[int64]*Task, auto *int64) error {
+	switch rec.Op {
+	case opCreate, opUpdate:
+		if rec.Task == nil {
+			return fmt.Errorf("%s without task", rec.Op)
+		}
+		t := *rec.Task
+		if t.Version == 0 {
+			// записи, сделанные до появления версий
+			t.Version = 1
+		}
+		tasks[t.ID] = &t
+		if t.ID > *auto {
+			*auto = t.ID
+		}
+	case opDelete:
+		delete(tasks, rec.ID)
+		if rec.ID > *auto {
+			*auto = rec.ID
+		}
+	case opBatch:
+		for _, r := range rec.Records {
+			if err := applyWALRecord(r, tasks, auto); err != nil {
+				return err
+			}
+		}
+	default:
+		return fmt.Errorf("unknown op %q", rec.Op)
+	}
+	return nil
+}
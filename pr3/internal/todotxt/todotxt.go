@@ -0,0 +1,211 @@
+// Package todotxt читает и пишет задачи в формате todo.txt
+// (https://github.com/todotxt/todo.txt).
+package todotxt
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+	"time"
+)
+
+// Item — одна строка todo.txt. Text — описание без +проектов, @контекстов
+// и расширений key:value, они вынесены в отдельные поля. Слова описания, которые
+// иначе прочитались бы как метки, в файле экранируются обратной косой чертой: \@mom.
+type Item struct {
+	Line       int
+	Done       bool
+	Priority   byte // 'A'..'Z', 0 — без приоритета
+	Completed  *time.Time
+	Created    *time.Time
+	Text       string
+	Projects   []string
+	Contexts   []string
+	Extensions map[string]string
+}
+
+type LineError struct {
+	Line int    `json:"line"`
+	Err  string `json:"error"`
+}
+
+func (e LineError) Error() string {
+	return fmt.Sprintf("line %d: %s", e.Line, e.Err)
+}
+
+const dateLayout = time.DateOnly
+
+// Parse разбирает todo.txt построчно. Пустые строки пропускаются, строки с ошибками
+// попадают в errs с номером строки и не мешают разбору остальных.
+func Parse(r io.Reader) (items []Item, errs []LineError, err error) {
+	sc := bufio.NewScanner(r)
+	sc.Buffer(make([]byte, 64*1024), 1<<20)
+	line := 0
+	for sc.Scan() {
+		line++
+		text := strings.TrimSpace(strings.TrimPrefix(sc.Text(), "\ufeff"))
+		if text == "" {
+			continue
+		}
+		item, perr := ParseLine(text)
+		if perr != nil {
+			errs = append(errs, LineError{Line: line, Err: perr.Error()})
+			continue
+		}
+		item.Line = line
+		items = append(items, item)
+	}
+	return items, errs, sc.Err()
+}
+
+func ParseLine(line string) (Item, error) {
+	var it Item
+	fields := strings.Fields(line)
+
+	if len(fields) > 0 && fields[0] == "x" {
+		it.Done = true
+		fields = fields[1:]
+		// у выполненной задачи первая дата — завершения, вторая — создания
+		if d, ok := parseDate(fields); ok {
+			it.Completed = d
+			fields = fields[1:]
+			if d, ok := parseDate(fields); ok {
+				it.Created = d
+				fields = fields[1:]
+			}
+		}
+	} else {
+		if len(fields) > 0 && isPriority(fields[0]) {
+			it.Priority = fields[0][1]
+			fields = fields[1:]
+		}
+		if d, ok := parseDate(fields); ok {
+			it.Created = d
+			fields = fields[1:]
+		}
+	}
+
+	var words []string
+	for _, f := range fields {
+		switch {
+		case len(f) > 1 && f[0] == '\\':
+			words = append(words, f[1:])
+		case isProject(f):
+			it.Projects = appendUnique(it.Projects, f[1:])
+		case isContext(f):
+			it.Contexts = appendUnique(it.Contexts, f[1:])
+		case isExtension(f):
+			key, value, _ := strings.Cut(f, ":")
+			if it.Extensions == nil {
+				it.Extensions = map[string]string{}
+			}
+			it.Extensions[key] = value
+		default:
+			words = append(words, f)
+		}
+	}
+	it.Text = strings.Join(words, " ")
+
+	if it.Text == "" {
+		return it, fmt.Errorf("task description is empty")
+	}
+	if it.Completed != nil && it.Created != nil && it.Completed.Before(*it.Created) {
+		return it, fmt.Errorf("completion date is before creation date")
+	}
+	return it, nil
+}
+
+// Format возвращает строку todo.txt. Проекты, контексты и расширения
+// (в алфавитном порядке ключей) записываются после описания.
+func Format(it Item) string {
+	var parts []string
+	if it.Done {
+		parts = append(parts, "x")
+		if it.Completed != nil {
+			parts = append(parts, it.Completed.Format(dateLayout))
+		}
+	} else if it.Priority != 0 {
+		parts = append(parts, "("+string(it.Priority)+")")
+	}
+	// дата создания у выполненной задачи допустима только вместе с датой завершения
+	if it.Created != nil && (!it.Done || it.Completed != nil) {
+		parts = append(parts, it.Created.Format(dateLayout))
+	}
+
+	parts = append(parts, escapeText(it.Text))
+	for _, p := range it.Projects {
+		parts = append(parts, "+"+p)
+	}
+	for _, c := range it.Contexts {
+		parts = append(parts, "@"+c)
+	}
+	keys := make([]string, 0, len(it.Extensions))
+	for k := range it.Extensions {
+		keys = append(keys, k)
+	}
+	slices.Sort(keys)
+	for _, k := range keys {
+		parts = append(parts, k+":"+it.Extensions[k])
+	}
+	return strings.Join(parts, " ")
+}
+
+func Write(w io.Writer, items []Item) error {
+	bw := bufio.NewWriter(w)
+	for _, it := range items {
+		if _, err := bw.WriteString(Format(it) + "\n"); err != nil {
+			return err
+		}
+	}
+	return bw.Flush()
+}
+
+func parseDate(fields []string) (*time.Time, bool) {
+	if len(fields) == 0 {
+		return nil, false
+	}
+	t, err := time.Parse(dateLayout, fields[0])
+	if err != nil {
+		return nil, false
+	}
+	return &t, true
+}
+
+func isPriority(s string) bool {
+	return len(s) == 3 && s[0] == '(' && s[2] == ')' && s[1] >= 'A' && s[1] <= 'Z'
+}
+
+// escapeText экранирует слова описания, которые при разборе ушли бы из него:
+// метки, слова с обратной косой чертой в начале, а первым словом — ещё и
+// отметку x, приоритет и дату. ParseLine снимает одну косую черту.
+func escapeText(text string) string {
+	words := strings.Fields(text)
+	for i, w := range words {
+		_, date := parseDate([]string{w})
+		if isProject(w) || isContext(w) || isExtension(w) || (len(w) > 1 && w[0] == '\\') ||
+			(i == 0 && (w == "x" || isPriority(w) || date)) {
+			words[i] = `\` + w
+		}
+	}
+	return strings.Join(words, " ")
+}
+
+func isProject(s string) bool { return len(s) > 1 && s[0] == '+' }
+
+func isContext(s string) bool { return len(s) > 1 && s[0] == '@' }
+
+// isExtension — key:value без пробелов и дополнительных двоеточий;
+// значения, начинающиеся с "/", не считаются расширениями, чтобы не ломать ссылки.
+func isExtension(s string) bool {
+	key, value, ok := strings.Cut(s, ":")
+	return ok && key != "" && value != "" && !strings.Contains(value, ":") && !strings.HasPrefix(value, "/")
+}
+
+func appendUnique(list []string, v string) []string {
+	if slices.Contains(list, v) {
+		return list
+	}
+	return append(list, v)
+}
@@ -0,0 +1,87 @@
+package todotxt
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseLine_DoneDates(t *testing.T) {
+	it, err := ParseLine("x 2024-01-12 Pay rent")
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	if !it.Done || it.Completed == nil || it.Created != nil || it.Text != "Pay rent" {
+		t.Errorf("expected completion date only, got %+v", it)
+	}
+	if got := Format(it); got != "x 2024-01-12 Pay rent" {
+		t.Errorf("unexpected format: %q", got)
+	}
+
+	it, err = ParseLine("x Pay rent")
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	if !it.Done || it.Completed != nil || it.Created != nil || it.Text != "Pay rent" {
+		t.Errorf("expected done task without dates, got %+v", it)
+	}
+	if got := Format(it); got != "x Pay rent" {
+		t.Errorf("unexpected format: %q", got)
+	}
+
+	if _, err := ParseLine("x 2024-01-01 2024-01-12 Pay rent"); err == nil {
+		t.Errorf("expected error for completion before creation")
+	}
+}
+
+func TestParseLine_URLIsNotExtension(t *testing.T) {
+	it, err := ParseLine("Read http://example.com/a docs:http://example.com/b owner:ann")
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	if it.Text != "Read http://example.com/a docs:http://example.com/b" {
+		t.Errorf("expected URLs to stay in the text, got %q", it.Text)
+	}
+	if len(it.Extensions) != 1 || it.Extensions["owner"] != "ann" {
+		t.Errorf("expected only owner extension, got %v", it.Extensions)
+	}
+}
+
+func TestParse_BOMAndLineNumbers(t *testing.T) {
+	items, errs, err := Parse(strings.NewReader("\ufeff(A) Call Mom @phone\n\nx\nBuy milk\n"))
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	if len(items) != 2 || items[0].Priority != 'A' || items[0].Text != "Call Mom" || items[1].Line != 4 {
+		t.Errorf("unexpected items: %+v", items)
+	}
+	if len(errs) != 1 || errs[0].Line != 3 {
+		t.Errorf("expected error on line 3, got %+v", errs)
+	}
+}
+
+func TestFormat_EscapesTagLikeWords(t *testing.T) {
+	for _, text := range []string{
+		"Ping @alice about PR",
+		"Meeting at 10:30",
+		"Read chapter 3:2 +1",
+		`Path \server\share`,
+		"x marks the spot",
+		"(A) is a grade",
+		"2024-01-05 retro notes",
+	} {
+		it := Item{Done: true, Text: text, Projects: []string{"work"}}
+		line := Format(it)
+		got, err := ParseLine(line)
+		if err != nil {
+			t.Errorf("%q: parse %q: %v", text, line, err)
+			continue
+		}
+		if got.Text != text || !got.Done || got.Completed != nil || len(got.Projects) != 1 || len(got.Contexts) != 0 || got.Extensions != nil {
+			t.Errorf("%q: round trip via %q gave %+v", text, line, got)
+		}
+	}
+
+	if got := Format(Item{Text: "Call @mom at 10:30"}); got != `Call \@mom at \10:30` {
+		t.Errorf("unexpected format: %q", got)
+	}
+}